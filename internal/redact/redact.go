@@ -0,0 +1,125 @@
+// Package redact masks credentials before requests and commands are logged.
+package redact
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Mask replaces redacted values.
+const Mask = "[REDACTED]"
+
+var sensitiveKeys = map[string]bool{
+	"authorization":  true,
+	"cookie":         true,
+	"set-cookie":     true,
+	"password":       true,
+	"pwd":            true,
+	"old_password":   true,
+	"new_password":   true,
+	"sid":            true,
+	"token":          true,
+	"access_token":   true,
+	"refresh_token":  true,
+	"id_token":       true,
+	"simple_token":   true,
+	"client_secret":  true,
+	"code_verifier":  true,
+	"device_code":    true,
+	"x-qnap-sid":     true,
+	"x-access-token": true,
+}
+
+// IsSensitive reports whether values under key, a header name, query
+// parameter or JSON object key, must not be logged.
+func IsSensitive(key string) bool {
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+// Header returns a copy of h with sensitive values masked.
+func Header(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, v := range h {
+		if IsSensitive(k) {
+			c[k] = []string{Mask}
+		} else {
+			c[k] = v
+		}
+	}
+	return c
+}
+
+// URL returns u as a string with sensitive query parameters and user info masked.
+func URL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	c := *u
+	if c.User != nil {
+		c.User = url.User(Mask)
+	}
+	if c.RawQuery != "" {
+		c.RawQuery = values(c.Query()).Encode()
+	}
+	return c.String()
+}
+
+// Form returns the URL-encoded form b with sensitive fields masked. Input
+// that is not a form is returned masked entirely unless empty.
+func Form(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	q, err := url.ParseQuery(string(b))
+	if err != nil {
+		return Mask
+	}
+	return values(q).Encode()
+}
+
+func values(q url.Values) url.Values {
+	for k := range q {
+		if IsSensitive(k) {
+			q[k] = []string{Mask}
+		}
+	}
+	return q
+}
+
+// JSON returns b with the values of sensitive object keys masked at any
+// depth. Input that is not JSON is returned masked entirely unless empty, as
+// it cannot be inspected.
+func JSON(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return Mask
+	}
+	out, err := json.Marshal(value(v))
+	if err != nil {
+		return Mask
+	}
+	return string(out)
+}
+
+func value(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, e := range x {
+			if IsSensitive(k) {
+				x[k] = Mask
+			} else {
+				x[k] = value(e)
+			}
+		}
+	case []interface{}:
+		for i, e := range x {
+			x[i] = value(e)
+		}
+	}
+	return v
+}
@@ -0,0 +1,72 @@
+// Package metrics collects Prometheus metrics of the myQNAPcloud account and
+// QTS clients. Create one Collector and pass it to both clients, e.g. with
+// account.WithMetrics and qts.WithMetrics.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcome is the result of a call, used as the outcome label.
+type Outcome string
+
+const (
+	Success Outcome = "success"
+	Failure Outcome = "error"
+)
+
+// Collector records the number, latency and concurrency of client calls,
+// labelled by client ("myqnapcloud" or "qts") and operation, e.g. "me.get"
+// or "qts.login". A nil *Collector records nothing.
+type Collector struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+// NewCollector creates a Collector and registers its metrics on reg.
+func NewCollector(reg prometheus.Registerer) (*Collector, error) {
+	c := &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "qeek_client",
+			Name:      "requests_total",
+			Help:      "Number of client calls by operation, outcome and error code.",
+		}, []string{"client", "operation", "outcome", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "qeek_client",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of client calls by operation and outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"client", "operation", "outcome"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "qeek_client",
+			Name:      "requests_in_flight",
+			Help:      "Number of client calls in progress by operation.",
+		}, []string{"client", "operation"}),
+	}
+	for _, m := range []prometheus.Collector{c.requests, c.duration, c.inFlight} {
+		if err := reg.Register(m); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Start records the start of a call of operation op by client. The returned
+// func records its end with the outcome and code, an HTTP status or API error
+// code, or "" when there is none.
+func (c *Collector) Start(client, op string) func(outcome Outcome, code string) {
+	if c == nil {
+		return func(Outcome, string) {}
+	}
+	start := time.Now()
+	inFlight := c.inFlight.WithLabelValues(client, op)
+	inFlight.Inc()
+	return func(outcome Outcome, code string) {
+		inFlight.Dec()
+		c.requests.WithLabelValues(client, op, string(outcome), code).Inc()
+		c.duration.WithLabelValues(client, op, string(outcome)).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,51 @@
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qeek-dev/qeek-api-go-client/metrics"
+)
+
+func TestCollector_Start(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c, err := metrics.NewCollector(reg)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	done := c.Start("qts", "qts.login")
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP qeek_client_requests_in_flight Number of client calls in progress by operation.
+# TYPE qeek_client_requests_in_flight gauge
+qeek_client_requests_in_flight{client="qts",operation="qts.login"} 1
+`), "qeek_client_requests_in_flight"))
+
+	done(metrics.Failure, "4000201")
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP qeek_client_requests_in_flight Number of client calls in progress by operation.
+# TYPE qeek_client_requests_in_flight gauge
+qeek_client_requests_in_flight{client="qts",operation="qts.login"} 0
+# HELP qeek_client_requests_total Number of client calls by operation, outcome and error code.
+# TYPE qeek_client_requests_total counter
+qeek_client_requests_total{client="qts",code="4000201",operation="qts.login",outcome="error"} 1
+`), "qeek_client_requests_in_flight", "qeek_client_requests_total"))
+	assert.Equal(t, 1, testutil.CollectAndCount(reg, "qeek_client_request_duration_seconds"))
+}
+
+func TestNewCollector_AlreadyRegistered(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	_, err := metrics.NewCollector(reg)
+	assert.NoError(t, err)
+	_, err = metrics.NewCollector(reg)
+	assert.Error(t, err)
+}
+
+func TestCollector_Nil(t *testing.T) {
+	var c *metrics.Collector
+	assert.NotPanics(t, func() { c.Start("myqnapcloud", "me.get")(metrics.Success, "200") })
+}
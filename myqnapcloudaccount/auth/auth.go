@@ -0,0 +1,95 @@
+// Package auth obtains OAuth2 tokens from myQNAPcloud for use with the account client:
+//
+//	cfg := &auth.Config{ClientID: id, ClientSecret: secret, Environment: account.Alpha}
+//	ts, err := cfg.PasswordTokenSource(ctx, username, password)
+//	if err != nil {
+//		...
+//	}
+//	s := account.New(oauth2.NewClient(ctx, ts), account.WithEnvironment(account.Alpha))
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+)
+
+// Endpoint returns the OAuth2 endpoints of the myQNAPcloud environment env,
+// under env.AuthBasePath or else env.BasePath. The paths are assumed, not
+// taken from a myQNAPcloud specification.
+func Endpoint(env account.Environment) oauth2.Endpoint {
+	base := env.AuthBasePath
+	if base == "" {
+		base = env.BasePath
+	}
+	base = strings.TrimRight(base, "/")
+	return oauth2.Endpoint{
+		AuthURL:       base + "/oauth/authorize",
+		TokenURL:      base + "/oauth/token",
+		DeviceAuthURL: base + "/oauth/device/code",
+		AuthStyle:     oauth2.AuthStyleInHeader,
+	}
+}
+
+// Config describes a myQNAPcloud OAuth2 client.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+
+	// Environment selects the authorization server, it defaults to
+	// account.DefaultEnvironment() and should match the one given to account.New.
+	Environment account.Environment
+}
+
+func (c *Config) environment() account.Environment {
+	if c.Environment.BasePath == "" {
+		return account.DefaultEnvironment()
+	}
+	return c.Environment
+}
+
+// OAuth2Config returns the golang.org/x/oauth2 configuration of c.
+func (c *Config) OAuth2Config() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		Scopes:       c.Scopes,
+		Endpoint:     Endpoint(c.environment()),
+	}
+}
+
+// PasswordTokenSource signs in with the resource owner password grant and
+// returns a TokenSource refreshing the obtained token when it expires.
+// The HTTP client used for token requests can be set with the oauth2.HTTPClient context key.
+func (c *Config) PasswordTokenSource(ctx context.Context, username, password string) (oauth2.TokenSource, error) {
+	conf := c.OAuth2Config()
+	t, err := conf.PasswordCredentialsToken(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+	return conf.TokenSource(ctx, t), nil
+}
+
+// ClientCredentialsTokenSource returns a TokenSource obtaining tokens for the
+// client itself with the client credentials grant.
+func (c *Config) ClientCredentialsTokenSource(ctx context.Context) oauth2.TokenSource {
+	conf := &clientcredentials.Config{
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		Scopes:       c.Scopes,
+		TokenURL:     Endpoint(c.environment()).TokenURL,
+		AuthStyle:    oauth2.AuthStyleInHeader,
+	}
+	return conf.TokenSource(ctx)
+}
+
+// TokenSource returns a TokenSource returning t until it expires, then
+// refreshing it with its refresh token.
+func (c *Config) TokenSource(ctx context.Context, t *oauth2.Token) oauth2.TokenSource {
+	return c.OAuth2Config().TokenSource(ctx, t)
+}
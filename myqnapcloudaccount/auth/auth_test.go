@@ -0,0 +1,212 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+
+	"github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/auth"
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+)
+
+type AuthTestCaseSuite struct {
+	ts  *httptest.Server
+	env account.Environment
+	cfg *auth.Config
+
+	grants []string
+	// Authorization headers received by the API
+	authorizations []string
+
+	// device login outcome after the first pending poll: approve, deny or
+	// empty to keep it pending
+	deviceOutcome   string
+	deviceExpiresIn int
+	devicePolls     int
+
+	// PKCE challenge and redirect URI received by the authorize endpoint
+	challenge   string
+	redirectURI string
+}
+
+func setupAuthTestCase(t *testing.T) (*AuthTestCaseSuite, func(t *testing.T)) {
+	s := &AuthTestCaseSuite{}
+	s.ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/token":
+			if id, secret, ok := r.BasicAuth(); !ok || id != "client-id" || secret != "client-secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"invalid_client"}`))
+				return
+			}
+			grant := r.PostFormValue("grant_type")
+			s.grants = append(s.grants, grant)
+			w.Header().Set("Content-Type", "application/json")
+			switch {
+			case grant == "password" && r.PostFormValue("username") == "gary" && r.PostFormValue("password") == "zxcv":
+				// expires right away, so the next use refreshes it
+				w.Write([]byte(`{"access_token":"password-token","token_type":"bearer","refresh_token":"refresh-1","expires_in":1}`))
+			case grant == "refresh_token" && r.PostFormValue("refresh_token") == "refresh-1":
+				w.Write([]byte(`{"access_token":"refreshed-token","token_type":"bearer","refresh_token":"refresh-2","expires_in":3600}`))
+			case grant == "urn:ietf:params:oauth:grant-type:device_code" && r.PostFormValue("device_code") == "device-code":
+				s.devicePolls++
+				switch {
+				case s.devicePolls == 1 || s.deviceOutcome == "":
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"error":"authorization_pending"}`))
+				case s.deviceOutcome == "approve":
+					w.Write([]byte(`{"access_token":"device-token","token_type":"bearer","refresh_token":"refresh-1","expires_in":3600}`))
+				case s.deviceOutcome == "deny":
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"error":"access_denied"}`))
+				}
+			case grant == "authorization_code" && r.PostFormValue("code") == "auth-code":
+				sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+				if base64.RawURLEncoding.EncodeToString(sum[:]) != s.challenge || r.PostFormValue("redirect_uri") != s.redirectURI {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"error":"invalid_grant"}`))
+					return
+				}
+				w.Write([]byte(`{"access_token":"browser-token","token_type":"bearer","refresh_token":"refresh-1","expires_in":3600}`))
+			case grant == "client_credentials":
+				w.Write([]byte(`{"access_token":"client-token","token_type":"bearer","expires_in":3600}`))
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_grant"}`))
+			}
+		case "/oauth/authorize":
+			q := r.URL.Query()
+			if q.Get("client_id") != "client-id" || q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" {
+				http.Error(w, "invalid request", http.StatusBadRequest)
+				return
+			}
+			s.challenge, s.redirectURI = q.Get("code_challenge"), q.Get("redirect_uri")
+			http.Redirect(w, r, s.redirectURI+"?code=auth-code&state="+url.QueryEscape(q.Get("state")), http.StatusFound)
+		case "/oauth/device/code":
+			if r.PostFormValue("client_id") != "client-id" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"invalid_client"}`))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"device_code":"device-code","user_code":"WDJB-MJHT","verification_uri":"%s/device","expires_in":%d,"interval":1}`, s.ts.URL, s.deviceExpiresIn)
+		case "/v1.1/me":
+			s.authorizations = append(s.authorizations, r.Header.Get("Authorization"))
+			w.Write([]byte(`{"message":"OK","code":0,"result":{"user_id":"gary"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	s.env = account.Environment{BasePath: s.ts.URL, APIVersion: "v1.1"}
+	s.cfg = &auth.Config{ClientID: "client-id", ClientSecret: "client-secret", Environment: s.env}
+
+	return s, func(t *testing.T) {
+		s.ts.Close()
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	e := auth.Endpoint(account.Alpha)
+	assert.Equal(t, account.Alpha.AuthBasePath+"/oauth/authorize", e.AuthURL)
+	assert.Equal(t, account.Alpha.AuthBasePath+"/oauth/token", e.TokenURL)
+	assert.Equal(t, account.Alpha.AuthBasePath+"/oauth/device/code", e.DeviceAuthURL)
+
+	e = auth.Endpoint(account.Environment{BasePath: "https://api.example.com", AuthBasePath: "https://auth.example.com/"})
+	assert.Equal(t, "https://auth.example.com/oauth/token", e.TokenURL)
+	e = auth.Endpoint(account.Environment{BasePath: "https://api.example.com"})
+	assert.Equal(t, "https://api.example.com/oauth/token", e.TokenURL)
+
+	cfg := &auth.Config{}
+	assert.Equal(t, account.DefaultEnvironment().AuthBasePath+"/oauth/token", cfg.OAuth2Config().Endpoint.TokenURL)
+}
+
+func TestConfig_PasswordTokenSource(t *testing.T) {
+	s, teardownTestCase := setupAuthTestCase(t)
+	defer teardownTestCase(t)
+
+	tt := []struct {
+		name               string
+		givenUsername      string
+		givenPassword      string
+		wantAuthorizations []string
+		wantGrants         []string
+		wantError          bool
+	}{
+		{
+			name:               "sign in then refresh",
+			givenUsername:      "gary",
+			givenPassword:      "zxcv",
+			wantAuthorizations: []string{"Bearer refreshed-token", "Bearer refreshed-token"},
+			wantGrants:         []string{"password", "refresh_token"},
+		},
+		{
+			name:          "fail with wrong password",
+			givenUsername: "gary",
+			givenPassword: "dddd",
+			wantGrants:    []string{"password"},
+			wantError:     true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s.grants, s.authorizations = nil, nil
+			ctx := context.WithValue(context.Background(), oauth2.HTTPClient, s.ts.Client())
+
+			ts, err := s.cfg.PasswordTokenSource(ctx, tc.givenUsername, tc.givenPassword)
+			if tc.wantError {
+				assert.Error(t, err)
+			} else if assert.NoError(t, err) {
+				// the password token expires within the oauth2 expiry delta, so it is refreshed on first use
+				service := account.New(oauth2.NewClient(ctx, ts), account.WithEnvironment(s.env))
+				for i := 0; i < 2; i++ {
+					_, err := service.Me.Get().Do()
+					assert.NoError(t, err)
+				}
+				assert.Equal(t, tc.wantAuthorizations, s.authorizations)
+			}
+			assert.Equal(t, tc.wantGrants, s.grants)
+		})
+	}
+}
+
+func TestConfig_ClientCredentialsTokenSource(t *testing.T) {
+	s, teardownTestCase := setupAuthTestCase(t)
+	defer teardownTestCase(t)
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, s.ts.Client())
+	service := account.New(oauth2.NewClient(ctx, s.cfg.ClientCredentialsTokenSource(ctx)), account.WithEnvironment(s.env))
+
+	_, err := service.Me.Get().Do()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bearer client-token"}, s.authorizations)
+	assert.Equal(t, []string{"client_credentials"}, s.grants)
+
+	bad := &auth.Config{ClientID: "client-id", ClientSecret: "wrong", Environment: s.env}
+	_, err = bad.ClientCredentialsTokenSource(ctx).Token()
+	assert.Error(t, err)
+}
+
+func TestConfig_TokenSource(t *testing.T) {
+	s, teardownTestCase := setupAuthTestCase(t)
+	defer teardownTestCase(t)
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, s.ts.Client())
+	expired := &oauth2.Token{AccessToken: "old", RefreshToken: "refresh-1", Expiry: time.Now().Add(-time.Minute)}
+
+	tok, err := s.cfg.TokenSource(ctx, expired).Token()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "refreshed-token", tok.AccessToken)
+		assert.Equal(t, "refresh-2", tok.RefreshToken)
+	}
+	assert.Equal(t, []string{"refresh_token"}, s.grants)
+}
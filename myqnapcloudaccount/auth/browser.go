@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+// BrowserLogin signs in through the browser with the authorization code grant
+// and PKCE, for desktop tools. It listens on a random loopback port, hands the
+// authorize URL to open, e.g. to launch the system browser, waits for the
+// redirect carrying its state and exchanges the code for a token. Requests to
+// the callback with another state, e.g. forged or prefetched, are answered
+// with 400 Bad Request and do not end the login.
+func (c *Config) BrowserLogin(ctx context.Context, open func(authURL string) error) (*oauth2.Token, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	conf := c.OAuth2Config()
+	conf.RedirectURL = fmt.Sprintf("http://%s/callback", ln.Addr())
+
+	state, err := randomState()
+	if err != nil {
+		ln.Close()
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	type result struct {
+		code string
+		err  error
+	}
+	done := make(chan result, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("state") != state {
+			http.Error(w, "Invalid sign-in state.", http.StatusBadRequest)
+			return
+		}
+
+		var res result
+		switch {
+		case q.Get("error") != "":
+			msg := q.Get("error")
+			if d := q.Get("error_description"); d != "" {
+				msg += ": " + d
+			}
+			res.err = fmt.Errorf("auth: authorization failed: %s", msg)
+		case q.Get("code") == "":
+			res.err = errors.New("auth: authorization redirect without code")
+		default:
+			res.code = q.Get("code")
+		}
+
+		if res.err != nil {
+			http.Error(w, "Sign-in failed, you can close this window.", http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Signed in to myQNAPcloud, you can close this window.")
+		}
+		select {
+		case done <- res:
+		default:
+		}
+	})
+
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	if err := open(conf.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-done:
+		if res.err != nil {
+			return nil, res.err
+		}
+		return conf.Exchange(ctx, res.code, oauth2.VerifierOption(verifier))
+	}
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,124 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+// visit stands in for the browser, following the redirects from authURL.
+func visit(authURL string) error {
+	resp, err := http.Get(authURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// redirect stands in for a browser redirected back to the login with query
+// built by f from the authorize URL parameters.
+func redirect(f func(q url.Values) url.Values) func(string) error {
+	return func(authURL string) error {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+		q := u.Query()
+		return visit(q.Get("redirect_uri") + "?" + f(q).Encode())
+	}
+}
+
+// forged stands in for another local client hitting the callback with a code
+// and a state of its own, returning the status of the reply.
+func forged(authURL string) (int, error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return 0, err
+	}
+	q := url.Values{"code": {"auth-code"}, "state": {"forged"}}
+	resp, err := http.Get(u.Query().Get("redirect_uri") + "?" + q.Encode())
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func TestConfig_BrowserLogin(t *testing.T) {
+	s, teardownTestCase := setupAuthTestCase(t)
+	defer teardownTestCase(t)
+
+	tt := []struct {
+		name      string
+		givenOpen func(authURL string) error
+		wantToken string
+		wantError error
+	}{
+		{
+			name:      "success",
+			givenOpen: visit,
+			wantToken: "browser-token",
+		},
+		{
+			name: "success after a forged redirect",
+			givenOpen: func(authURL string) error {
+				status, err := forged(authURL)
+				if err != nil {
+					return err
+				}
+				if status != http.StatusBadRequest {
+					return fmt.Errorf("forged redirect answered with %d", status)
+				}
+				return visit(authURL)
+			},
+			wantToken: "browser-token",
+		},
+		{
+			name:      "fail when only forged redirects come back",
+			givenOpen: func(authURL string) error { _, err := forged(authURL); return err },
+			wantError: context.DeadlineExceeded,
+		},
+		{
+			name: "fail with access denied",
+			givenOpen: redirect(func(q url.Values) url.Values {
+				return url.Values{"error": {"access_denied"}, "state": {q.Get("state")}}
+			}),
+			wantError: errors.New("auth: authorization failed: access_denied"),
+		},
+		{
+			name:      "fail with open error",
+			givenOpen: func(string) error { return errors.New("no browser") },
+			wantError: errors.New("no browser"),
+		},
+		{
+			name:      "fail when the browser never comes back",
+			givenOpen: func(string) error { return nil },
+			wantError: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), oauth2.HTTPClient, s.ts.Client())
+			ctx, cancel := context.WithTimeout(ctx, time.Second)
+			defer cancel()
+
+			tok, err := s.cfg.BrowserLogin(ctx, tc.givenOpen)
+			if tc.wantError != nil {
+				assert.Equal(t, tc.wantError, err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tc.wantToken, tok.AccessToken)
+			}
+		})
+	}
+}
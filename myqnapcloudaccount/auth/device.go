@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+var (
+	// ErrDeviceAccessDenied is returned when the user declines the device login.
+	ErrDeviceAccessDenied = errors.New("auth: device login denied")
+
+	// ErrDeviceCodeExpired is returned when the user did not approve the device
+	// login before the device code expired.
+	ErrDeviceCodeExpired = errors.New("auth: device code expired")
+)
+
+// DevicePrompt shows the user where to approve a device login.
+type DevicePrompt func(*oauth2.DeviceAuthResponse) error
+
+// WritePrompt returns a DevicePrompt printing the verification URL and user code to w.
+func WritePrompt(w io.Writer) DevicePrompt {
+	return func(da *oauth2.DeviceAuthResponse) error {
+		if da.VerificationURIComplete != "" {
+			_, err := fmt.Fprintf(w, "To sign in to myQNAPcloud, open %s\nor visit %s and enter the code %s\n", da.VerificationURIComplete, da.VerificationURI, da.UserCode)
+			return err
+		}
+		_, err := fmt.Fprintf(w, "To sign in to myQNAPcloud, visit %s and enter the code %s\n", da.VerificationURI, da.UserCode)
+		return err
+	}
+}
+
+// DeviceLogin signs in with the OAuth2 device authorization grant, for tools
+// running where no browser can be opened. It requests a device code, hands it
+// to prompt and polls the authorization server, slowing down when asked to,
+// until the user approves or denies the login or the code expires.
+func (c *Config) DeviceLogin(ctx context.Context, prompt DevicePrompt) (*oauth2.Token, error) {
+	conf := c.OAuth2Config()
+	da, err := conf.DeviceAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := prompt(da); err != nil {
+		return nil, err
+	}
+
+	t, err := conf.DeviceAccessToken(ctx, da)
+	if err != nil {
+		var re *oauth2.RetrieveError
+		switch {
+		case errors.As(err, &re) && re.ErrorCode == "access_denied":
+			return nil, ErrDeviceAccessDenied
+		case errors.As(err, &re) && re.ErrorCode == "expired_token",
+			errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+			return nil, ErrDeviceCodeExpired
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+// DeviceTokenSource returns a TokenSource running DeviceLogin on first use and
+// refreshing the obtained token afterwards. Pass it as the login of
+// CachingTokenSource to only prompt when no token is stored.
+func (c *Config) DeviceTokenSource(ctx context.Context, prompt DevicePrompt) oauth2.TokenSource {
+	return &deviceTokenSource{ctx: ctx, c: c, prompt: prompt}
+}
+
+type deviceTokenSource struct {
+	ctx    context.Context
+	c      *Config
+	prompt DevicePrompt
+
+	mu sync.Mutex
+	ts oauth2.TokenSource
+}
+
+func (s *deviceTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ts == nil {
+		t, err := s.c.DeviceLogin(s.ctx, s.prompt)
+		if err != nil {
+			return nil, err
+		}
+		s.ts = s.c.TokenSource(s.ctx, t)
+	}
+	return s.ts.Token()
+}
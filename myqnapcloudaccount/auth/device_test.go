@@ -0,0 +1,89 @@
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+
+	"github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/auth"
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+)
+
+func TestConfig_DeviceLogin(t *testing.T) {
+	s, teardownTestCase := setupAuthTestCase(t)
+	defer teardownTestCase(t)
+
+	tt := []struct {
+		name           string
+		givenOutcome   string
+		givenExpiresIn int
+		wantToken      string
+		wantPolls      int
+		wantError      error
+	}{
+		{
+			name:           "approved",
+			givenOutcome:   "approve",
+			givenExpiresIn: 60,
+			wantToken:      "device-token",
+			wantPolls:      2,
+		},
+		{
+			name:           "fail with denied",
+			givenOutcome:   "deny",
+			givenExpiresIn: 60,
+			wantPolls:      2,
+			wantError:      auth.ErrDeviceAccessDenied,
+		},
+		{
+			name:           "fail with expired",
+			givenExpiresIn: 1,
+			wantError:      auth.ErrDeviceCodeExpired,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s.deviceOutcome, s.deviceExpiresIn, s.devicePolls = tc.givenOutcome, tc.givenExpiresIn, 0
+			ctx := context.WithValue(context.Background(), oauth2.HTTPClient, s.ts.Client())
+
+			out := new(bytes.Buffer)
+			tok, err := s.cfg.DeviceLogin(ctx, auth.WritePrompt(out))
+			assert.Contains(t, out.String(), s.ts.URL+"/device")
+			assert.Contains(t, out.String(), "WDJB-MJHT")
+			if tc.wantError != nil {
+				assert.Equal(t, tc.wantError, err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tc.wantToken, tok.AccessToken)
+			}
+			if tc.wantPolls > 0 {
+				assert.Equal(t, tc.wantPolls, s.devicePolls)
+			}
+		})
+	}
+}
+
+func TestConfig_DeviceTokenSource(t *testing.T) {
+	s, teardownTestCase := setupAuthTestCase(t)
+	defer teardownTestCase(t)
+
+	s.deviceOutcome, s.deviceExpiresIn = "approve", 60
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, s.ts.Client())
+
+	prompts := 0
+	ts := s.cfg.DeviceTokenSource(ctx, func(da *oauth2.DeviceAuthResponse) error {
+		prompts++
+		return nil
+	})
+
+	service := account.New(oauth2.NewClient(ctx, ts), account.WithEnvironment(s.env))
+	for i := 0; i < 2; i++ {
+		_, err := service.Me.Get().Do()
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []string{"Bearer device-token", "Bearer device-token"}, s.authorizations)
+	assert.Equal(t, 1, prompts, "the user was expected to be prompted once")
+}
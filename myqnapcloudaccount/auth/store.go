@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// ErrNoToken is returned by TokenStore.Load when no token has been saved yet.
+var ErrNoToken = errors.New("auth: no token stored")
+
+// TokenStore persists a token across restarts.
+// Implementations must be safe for concurrent use.
+type TokenStore interface {
+	Load() (*oauth2.Token, error)
+	Save(*oauth2.Token) error
+}
+
+// MemoryStore keeps the token in memory. The zero value is ready to use.
+type MemoryStore struct {
+	mu  sync.Mutex
+	tok *oauth2.Token
+}
+
+func (m *MemoryStore) Load() (*oauth2.Token, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tok == nil {
+		return nil, ErrNoToken
+	}
+	t := *m.tok
+	return &t, nil
+}
+
+func (m *MemoryStore) Save(t *oauth2.Token) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := *t
+	m.tok = &c
+	return nil
+}
+
+// FileStore keeps the token as JSON in a file readable only by its owner.
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (f *FileStore) Load() (*oauth2.Token, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil, ErrNoToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t := &oauth2.Token{}
+	if err := json.Unmarshal(b, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Save writes t to a temporary file next to the store and renames it over the
+// store, so readers never see a partially written token.
+func (f *FileStore) Save(t *oauth2.Token) (err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(f.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err = tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// RefreshWindow is how long before its expiry a stored token is refreshed.
+const RefreshWindow = time.Minute
+
+// CachingTokenSource returns a TokenSource backed by store. The stored token
+// is refreshed RefreshWindow before it expires and the new token is saved
+// back, so other processes sharing the store pick it up. When the store is
+// empty the first token comes from login, which may be nil to fail with
+// ErrNoToken instead.
+//
+// The returned TokenSource is safe for concurrent use, a single instance can
+// be shared by several account.Service.
+func (c *Config) CachingTokenSource(ctx context.Context, store TokenStore, login oauth2.TokenSource) oauth2.TokenSource {
+	return &cachingTokenSource{ctx: ctx, conf: c.OAuth2Config(), store: store, login: login}
+}
+
+type cachingTokenSource struct {
+	ctx   context.Context
+	conf  *oauth2.Config
+	store TokenStore
+	login oauth2.TokenSource
+
+	mu  sync.Mutex
+	tok *oauth2.Token
+}
+
+func fresh(t *oauth2.Token) bool {
+	return t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || time.Until(t.Expiry) > RefreshWindow)
+}
+
+func (s *cachingTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if fresh(s.tok) {
+		return s.tok, nil
+	}
+
+	// another instance sharing the store may have refreshed it already
+	t, err := s.store.Load()
+	if err != nil && err != ErrNoToken {
+		return nil, err
+	}
+	if fresh(t) {
+		s.tok = t
+		return t, nil
+	}
+
+	switch {
+	case t != nil && t.RefreshToken != "":
+		// pretend the token expired now, so the oauth2 package refreshes it
+		expired := *t
+		expired.Expiry = time.Now().Add(-time.Second)
+		t, err = s.conf.TokenSource(s.ctx, &expired).Token()
+	case s.login != nil:
+		t, err = s.login.Token()
+	case t != nil:
+		return nil, errors.New("auth: stored token expired and has no refresh token")
+	default:
+		return nil, ErrNoToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Save(t); err != nil {
+		return nil, err
+	}
+	s.tok = t
+	return t, nil
+}
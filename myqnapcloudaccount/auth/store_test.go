@@ -0,0 +1,155 @@
+package auth_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+
+	"github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/auth"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "token.json")
+	store := auth.NewFileStore(path)
+
+	_, err := store.Load()
+	assert.Equal(t, auth.ErrNoToken, err)
+
+	want := &oauth2.Token{AccessToken: "a", RefreshToken: "r", TokenType: "Bearer", Expiry: time.Now().Add(time.Hour).Round(time.Second)}
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, store.Save(want))
+	}
+
+	got, err := store.Load()
+	if assert.NoError(t, err) {
+		assert.Equal(t, want.AccessToken, got.AccessToken)
+		assert.Equal(t, want.RefreshToken, got.RefreshToken)
+		assert.True(t, want.Expiry.Equal(got.Expiry))
+	}
+
+	fi, err := os.Stat(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+	}
+
+	// no temporary files are left next to the store
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if assert.NoError(t, err) {
+		assert.Len(t, entries, 1)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := &auth.MemoryStore{}
+
+	_, err := store.Load()
+	assert.Equal(t, auth.ErrNoToken, err)
+
+	tok := &oauth2.Token{AccessToken: "a"}
+	assert.NoError(t, store.Save(tok))
+	tok.AccessToken = "changed"
+
+	got, err := store.Load()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "a", got.AccessToken)
+	}
+}
+
+func TestConfig_CachingTokenSource(t *testing.T) {
+	s, teardownTestCase := setupAuthTestCase(t)
+	defer teardownTestCase(t)
+
+	tt := []struct {
+		name        string
+		givenStored *oauth2.Token
+		givenLogin  oauth2.TokenSource
+		wantToken   string
+		wantGrants  []string
+		wantError   bool
+	}{
+		{
+			name:        "fresh stored token is used as is",
+			givenStored: &oauth2.Token{AccessToken: "stored", RefreshToken: "refresh-1", Expiry: time.Now().Add(time.Hour)},
+			wantToken:   "stored",
+		},
+		{
+			name:        "token about to expire is refreshed",
+			givenStored: &oauth2.Token{AccessToken: "stored", RefreshToken: "refresh-1", Expiry: time.Now().Add(30 * time.Second)},
+			wantToken:   "refreshed-token",
+			wantGrants:  []string{"refresh_token"},
+		},
+		{
+			name:       "empty store signs in",
+			givenLogin: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "login"}),
+			wantToken:  "login",
+		},
+		{
+			name:      "fail with empty store and no login",
+			wantError: true,
+		},
+		{
+			name:        "fail with expired token without refresh token",
+			givenStored: &oauth2.Token{AccessToken: "stored", Expiry: time.Now().Add(-time.Hour)},
+			wantError:   true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s.grants = nil
+			ctx := context.WithValue(context.Background(), oauth2.HTTPClient, s.ts.Client())
+			store := &auth.MemoryStore{}
+			if tc.givenStored != nil {
+				store.Save(tc.givenStored)
+			}
+
+			tok, err := s.cfg.CachingTokenSource(ctx, store, tc.givenLogin).Token()
+			if tc.wantError {
+				assert.Error(t, err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tc.wantToken, tok.AccessToken)
+				saved, _ := store.Load()
+				assert.Equal(t, tc.wantToken, saved.AccessToken, "the token was expected to be saved")
+			}
+			assert.Equal(t, tc.wantGrants, s.grants)
+		})
+	}
+}
+
+func TestConfig_CachingTokenSourceShared(t *testing.T) {
+	s, teardownTestCase := setupAuthTestCase(t)
+	defer teardownTestCase(t)
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, s.ts.Client())
+	store := auth.NewFileStore(filepath.Join(t.TempDir(), "token.json"))
+	store.Save(&oauth2.Token{AccessToken: "stored", RefreshToken: "refresh-1", Expiry: time.Now().Add(-time.Minute)})
+
+	// two sources, e.g. in two daemons, sharing the same file
+	a := s.cfg.CachingTokenSource(ctx, store, nil)
+	b := s.cfg.CachingTokenSource(ctx, store, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tok, err := a.Token()
+			if assert.NoError(t, err) {
+				assert.Equal(t, "refreshed-token", tok.AccessToken)
+			}
+		}()
+	}
+	wg.Wait()
+
+	tok, err := b.Token()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "refreshed-token", tok.AccessToken)
+	}
+	assert.Equal(t, []string{"refresh_token"}, s.grants)
+}
@@ -2,52 +2,127 @@ package account
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
-)
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 
-// default production env, if use go build will replace apiVersion and basePath
-var (
-	apiVersion = "v1.1"
-	basePath   = "https://core.api.myqnapcloud.com"
+	"github.com/qeek-dev/qeek-api-go-client/metrics"
 )
 
-func New(client *http.Client) *Service {
+// default production env, if use go build will replace defaultEnvironment
+var defaultEnvironment = Production
+
+func New(client *http.Client, opts ...Option) *Service {
 	if client == nil {
 		client = http.DefaultClient
 	}
-	s := &Service{client: client, BasePath: basePath}
+	s := &Service{client: client, BasePath: defaultEnvironment.BasePath, APIVersion: defaultEnvironment.APIVersion}
+	for _, opt := range opts {
+		opt(s)
+	}
 	s.Me = NewMeService(s)
+	s.Friend = NewFriendService(s)
+	s.User = NewUserService(s)
 	return s
 }
 
 type Service struct {
-	client    *http.Client
-	BasePath  string // API endpoint base URL
-	UserAgent string // optional additional User-Agent fragment
+	client     *http.Client
+	BasePath   string // API endpoint base URL
+	APIVersion string // API version prefixed to every path
+	UserAgent  string // optional additional User-Agent fragment
+
+	retryPolicy RetryPolicy
+	limiter     *rate.Limiter
+	middleware  []Middleware
+	logger      *slog.Logger
+	tracer      trace.Tracer
+	propagator  propagation.TextMapPropagator
+	metrics     *metrics.Collector
 
 	Me     *MeService
 	Friend *FriendService
 	User   *UserService
 
-	// Set to true to output debugging logs during API calls
+	// Set to true to log API calls to slog.Default when no logger was set
+	// with WithLogger
 	Debug bool
 }
 
-func versioned(path string) string {
-	return fmt.Sprintf("/%s/%s", apiVersion, strings.Trim(path, "/"))
+func contextOrBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+type operationKey struct{}
+
+// operation names an API call and the unversioned path template of its
+// endpoint, e.g. "friends/{user_id}/accept".
+type operation struct {
+	name  string
+	route string
+}
+
+// withOperation tags ctx with the logical operation name of an API call and
+// the path template of its endpoint.
+func withOperation(ctx context.Context, op, route string) context.Context {
+	return context.WithValue(contextOrBackground(ctx), operationKey{}, operation{op, route})
+}
+
+// Operation returns the logical operation name, e.g. "me.get", of the API
+// call a request context belongs to, for use in middleware.
+func Operation(ctx context.Context) string {
+	op, _ := ctx.Value(operationKey{}).(operation)
+	return op.name
+}
+
+// routeTemplate returns the path template of the API call ctx belongs to.
+func routeTemplate(ctx context.Context) string {
+	op, _ := ctx.Value(operationKey{}).(operation)
+	return op.route
+}
+
+func (c *Service) versioned(path string) string {
+	return fmt.Sprintf("/%s/%s", c.APIVersion, strings.Trim(path, "/"))
+}
+
+func withQuery(path string, params url.Values) string {
+	if len(params) == 0 {
+		return path
+	}
+	return path + "?" + params.Encode()
+}
+
+// restoreParam returns a func putting params[key] back to its current state.
+func restoreParam(params url.Values, key string) func() {
+	orig, ok := params[key]
+	return func() {
+		if ok {
+			params[key] = orig
+		} else {
+			params.Del(key)
+		}
+	}
 }
 
 // NewRequest creates an API request.
 // The path is expected to be a relative path and will be resolved
 // according to the BaseURL of the Client. Paths should always be specified without a preceding slash.
-func (c *Service) doRequest(method, path string, payload interface{}) (*http.Request, error) {
-	url := c.BasePath + path
-
+func (c *Service) doRequest(ctx context.Context, method, path string, payload interface{}) (*http.Request, error) {
 	body := new(bytes.Buffer)
 	if payload != nil {
 		err := json.NewEncoder(body).Encode(payload)
@@ -56,20 +131,27 @@ func (c *Service) doRequest(method, path string, payload interface{}) (*http.Req
 		}
 	}
 
-	req, err := http.NewRequest(method, url, body)
+	return c.newRequest(ctx, method, path, body, "application/json")
+}
+
+// newRequest creates an API request sending body as is with the given content type.
+func (c *Service) newRequest(ctx context.Context, method, path string, body io.Reader, contentType string) (*http.Request, error) {
+	url := c.BasePath + path
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 	req.Header.Add("Accept", "application/json")
 	//req.Header.Add("User-Agent", formatUserAgent(c.UserAgent))
 
 	return req, nil
 }
 
-func (c *Service) get(path string, obj interface{}) (*http.Response, error) {
-	req, err := c.doRequest("GET", path, nil)
+func (c *Service) get(ctx context.Context, path string, obj interface{}) (*http.Response, error) {
+	req, err := c.doRequest(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -77,8 +159,8 @@ func (c *Service) get(path string, obj interface{}) (*http.Response, error) {
 	return c.do(req, obj)
 }
 
-func (c *Service) post(path string, payload, obj interface{}) (*http.Response, error) {
-	req, err := c.doRequest("POST", path, payload)
+func (c *Service) post(ctx context.Context, path string, payload, obj interface{}) (*http.Response, error) {
+	req, err := c.doRequest(ctx, "POST", path, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -86,8 +168,8 @@ func (c *Service) post(path string, payload, obj interface{}) (*http.Response, e
 	return c.do(req, obj)
 }
 
-func (c *Service) put(path string, payload, obj interface{}) (*http.Response, error) {
-	req, err := c.doRequest("PUT", path, payload)
+func (c *Service) put(ctx context.Context, path string, payload, obj interface{}) (*http.Response, error) {
+	req, err := c.doRequest(ctx, "PUT", path, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -95,8 +177,8 @@ func (c *Service) put(path string, payload, obj interface{}) (*http.Response, er
 	return c.do(req, obj)
 }
 
-func (c *Service) patch(path string, payload, obj interface{}) (*http.Response, error) {
-	req, err := c.doRequest("PATCH", path, payload)
+func (c *Service) patch(ctx context.Context, path string, payload, obj interface{}) (*http.Response, error) {
+	req, err := c.doRequest(ctx, "PATCH", path, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -104,8 +186,8 @@ func (c *Service) patch(path string, payload, obj interface{}) (*http.Response,
 	return c.do(req, obj)
 }
 
-func (c *Service) delete(path string, payload interface{}, obj interface{}) (*http.Response, error) {
-	req, err := c.doRequest("DELETE", path, payload)
+func (c *Service) delete(ctx context.Context, path string, payload interface{}, obj interface{}) (*http.Response, error) {
+	req, err := c.doRequest(ctx, "DELETE", path, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -119,21 +201,25 @@ func (c *Service) delete(path string, payload interface{}, obj interface{}) (*ht
 // or returned as an error if an API error has occurred.
 // If obj implements the io.Writer interface, the raw response body will be written to obj,
 // without attempting to decode it.
-func (c *Service) do(req *http.Request, obj interface{}) (*http.Response, error) {
-	if c.Debug {
-		log.Printf("Executing request (%v): %#v", req.URL, req)
+func (c *Service) do(req *http.Request, obj interface{}) (resp *http.Response, err error) {
+	req, span := c.startSpan(req)
+	done := c.metrics.Start("myqnapcloud", Operation(req.Context()))
+	defer func() {
+		endSpan(span, resp, err)
+		done(outcome(resp, obj, err))
+	}()
+
+	var h Handler = c.send
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err = h(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if c.Debug {
-		log.Printf("Response received: %#v", resp)
-	}
-
 	err = CheckResponse(resp)
 	if err != nil {
 		return resp, err
@@ -143,44 +229,67 @@ func (c *Service) do(req *http.Request, obj interface{}) (*http.Response, error)
 	// the response body is decoded into v.
 	if obj != nil {
 		if w, ok := obj.(io.Writer); ok {
-			io.Copy(w, resp.Body)
+			_, err = io.Copy(w, resp.Body)
 		} else {
 			err = json.NewDecoder(resp.Body).Decode(obj)
+			if err == io.EOF {
+				// empty body, e.g. 204 No Content
+				err = nil
+			}
 		}
 	}
 
 	return resp, err
 }
 
-type GetUserResponse struct {
-	Message string `json:"message"`
-	Code    int    `json:"code"`
-	Result  struct {
-		FirstName    string `json:"first_name"`
-		LastName     string `json:"last_name"`
-		DisplayName  string `json:"display_name"`
-		Subscribed   bool   `json:"subscribed"`
-		Language     string `json:"language"`
-		Gender       int    `json:"gender"`
-		CreatedAt    string `json:"created_at"`
-		UpdatedAt    string `json:"updated_at"`
-		PortalNotify bool   `json:"portal_notify"`
-		SimpleToken  string `json:"simple_token"`
-		Brithday     string `json:"brithday"`
-		MobileNumber string `json:"mobile_number"`
-		UserId       string `json:"user_id"`
-		Email        string `json:"email"`
-		Avatars      struct {
-			Small  string `json:"small"`
-			Medium string `json:"medium"`
-			Cn     struct {
-				Small  string `json:"small"`
-				Medium string `json:"medium"`
-				Icon   string `json:"icon"`
-			} `json:"cn"`
-			Icon string `json:"icon"`
-		} `json:"avatars"`
-	} `json:"result"`
+// send sends req, waiting on the rate limiter and retrying according to the
+// retry policy. It is the innermost Handler of the middleware chain.
+func (c *Service) send(req *http.Request) (*http.Response, error) {
+	var resp *http.Response
+	var err error
+	for attempt := 1; ; attempt++ {
+		if c.limiter != nil {
+			if err = c.limiter.Wait(req.Context()); err != nil {
+				return nil, err
+			}
+		}
+
+		c.logRequest(req, attempt)
+		start := time.Now()
+		resp, err = c.client.Do(req)
+		c.logResponse(req, resp, err, attempt, time.Since(start))
+
+		delay, retry := c.retryPolicy.backoff(req, resp, err, attempt)
+		if !retry {
+			break
+		}
+		trace.SpanFromContext(req.Context()).AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", attempt)))
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if req, err = rewind(req, delay); err != nil {
+			return nil, err
+		}
+	}
+	return resp, err
+}
+
+// User is the profile of the current user.
+type User struct {
+	PublicProfile
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Subscribed   bool   `json:"subscribed"`
+	Language     string `json:"language"`
+	Gender       int    `json:"gender"`
+	CreatedAt    string `json:"created_at"`
+	UpdatedAt    string `json:"updated_at"`
+	PortalNotify bool   `json:"portal_notify"`
+	SimpleToken  string `json:"simple_token"`
+	Brithday     string `json:"brithday"`
+	MobileNumber string `json:"mobile_number"`
+	Email        string `json:"email"`
 }
 
 type MeService struct {
@@ -200,7 +309,8 @@ func NewMeService(s *Service) *MeService {
 }
 
 type MeGetCall struct {
-	s *Service
+	s   *Service
+	ctx context.Context
 }
 
 func (r *MeService) Get() *MeGetCall {
@@ -208,59 +318,106 @@ func (r *MeService) Get() *MeGetCall {
 	return c
 }
 
-func (c *MeGetCall) Do() (*GetUserResponse, error) {
-	path := versioned("me")
-	ret := &GetUserResponse{}
-	_, err := c.s.get(path, &ret)
-	if err != nil {
-		return nil, err
-	}
-	return ret, nil
+// Context sets the context to be used in this call's Do method.
+// Any pending HTTP request will be aborted if the provided context is canceled.
+func (c *MeGetCall) Context(ctx context.Context) *MeGetCall {
+	c.ctx = ctx
+	return c
 }
 
-type ActivityService struct {
-	s *Service
+func (c *MeGetCall) Do() (*User, error) {
+	return c.DoContext(c.ctx)
 }
 
-func NewActivityService(s *Service) *ActivityService {
-	rs := &ActivityService{s: s}
-	return rs
+// DoContext executes the call bound to ctx, overriding any context set with Context.
+func (c *MeGetCall) DoContext(ctx context.Context) (*User, error) {
+	path := c.s.versioned("me")
+	env := &Envelope[User]{}
+	resp, err := c.s.get(withOperation(ctx, "me.get", "me"), path, env)
+	return unwrap(resp, env, err)
 }
 
-type PasswordService struct {
-	s *Service
+// Me update call
+type MeUpdateCall struct {
+	s   *Service
+	ctx context.Context
+
+	fields map[string]interface{}
 }
 
-func NewPasswordService(s *Service) *PasswordService {
-	rs := &PasswordService{s: s}
-	return rs
+// Update changes the profile of the current user. Only the fields set on the
+// call are sent, the others are left untouched.
+func (r *MeService) Update() *MeUpdateCall {
+	c := &MeUpdateCall{s: r.s, fields: map[string]interface{}{}}
+	return c
 }
 
-type AvatarService struct {
-	s *Service
+func (c *MeUpdateCall) DisplayName(displayName string) *MeUpdateCall {
+	c.fields["display_name"] = displayName
+	return c
 }
 
-func NewAvatarService(s *Service) *AvatarService {
-	rs := &AvatarService{s: s}
-	return rs
+func (c *MeUpdateCall) FirstName(firstName string) *MeUpdateCall {
+	c.fields["first_name"] = firstName
+	return c
 }
 
-type FriendService struct {
-	s *Service
+func (c *MeUpdateCall) LastName(lastName string) *MeUpdateCall {
+	c.fields["last_name"] = lastName
+	return c
 }
 
-func NewFriendService(s *Service) *FriendService {
-	rs := &FriendService{s: s}
-	return rs
+func (c *MeUpdateCall) Language(language string) *MeUpdateCall {
+	c.fields["language"] = language
+	return c
 }
 
-type UserService struct {
-	s *Service
+func (c *MeUpdateCall) Gender(gender int) *MeUpdateCall {
+	c.fields["gender"] = gender
+	return c
 }
 
-func NewUserService(s *Service) *UserService {
-	rs := &UserService{s: s}
-	return rs
+func (c *MeUpdateCall) Brithday(brithday string) *MeUpdateCall {
+	c.fields["brithday"] = brithday
+	return c
+}
+
+func (c *MeUpdateCall) MobileNumber(mobileNumber string) *MeUpdateCall {
+	c.fields["mobile_number"] = mobileNumber
+	return c
+}
+
+func (c *MeUpdateCall) Subscribed(subscribed bool) *MeUpdateCall {
+	c.fields["subscribed"] = subscribed
+	return c
+}
+
+func (c *MeUpdateCall) PortalNotify(portalNotify bool) *MeUpdateCall {
+	c.fields["portal_notify"] = portalNotify
+	return c
+}
+
+// Context sets the context to be used in this call's Do method.
+// Any pending HTTP request will be aborted if the provided context is canceled.
+func (c *MeUpdateCall) Context(ctx context.Context) *MeUpdateCall {
+	c.ctx = ctx
+	return c
+}
+
+func (c *MeUpdateCall) Do() (*User, error) {
+	return c.DoContext(c.ctx)
+}
+
+// DoContext executes the call bound to ctx, overriding any context set with Context.
+func (c *MeUpdateCall) DoContext(ctx context.Context) (*User, error) {
+	if len(c.fields) == 0 {
+		return nil, errors.New("me update: no fields set")
+	}
+
+	path := c.s.versioned("me")
+	env := &Envelope[User]{}
+	resp, err := c.s.patch(withOperation(ctx, "me.update", "me"), path, c.fields, env)
+	return unwrap(resp, env, err)
 }
 
 //-----------------------------------------------------------------------------
@@ -270,37 +427,98 @@ type Response struct {
 	HttpResponse *http.Response
 }
 
+// Envelope is the wrapper of every myQNAPcloud API response body.
+// A Code other than CodeOK reports a failure even if the HTTP status is 2xx.
+type Envelope[T any] struct {
+	Message string    `json:"message"`
+	Code    ErrorCode `json:"code"`
+	Result  T         `json:"result"`
+}
+
+// unwrap returns the result of env, decoded from resp, or the error of the call.
+func unwrap[T any](resp *http.Response, env *Envelope[T], err error) (*T, error) {
+	if err != nil {
+		return nil, err
+	}
+	if env.Code != CodeOK {
+		errorResponse := newErrorResponse(resp)
+		errorResponse.Message = env.Message
+		errorResponse.Code = env.Code
+		return nil, errorResponse
+	}
+	return &env.Result, nil
+}
+
 // An ErrorResponse represents an API response that generated an error.
+// It matches the sentinel errors of this package with errors.Is, e.g.
+// errors.Is(err, ErrNotFound).
 type ErrorResponse struct {
 	Response
 
+	StatusCode int    `json:"-"`
+	Method     string `json:"-"`
+	URL        string `json:"-"`
+	RequestId  string `json:"-"` // X-Request-Id header of the response
+	Body       string `json:"-"` // leading part of the raw response body
+
 	// human-readable message
-	Message string `json:"message"`
-	Code    int    `json:"code"`
+	Message string    `json:"message"`
+	Code    ErrorCode `json:"code"`
 }
 
 // Error implements the error interface.
 func (r *ErrorResponse) Error() string {
-	return fmt.Sprintf("%v %v: %v %v",
-		r.HttpResponse.Request.Method, r.HttpResponse.Request.URL,
-		r.HttpResponse.StatusCode, r.Message)
+	msg := r.Message
+	if msg == "" {
+		msg = r.Body
+	}
+	s := fmt.Sprintf("%v %v: %v %v", r.Method, r.URL, r.StatusCode, msg)
+	if r.Code != 0 {
+		s += fmt.Sprintf(" (code %d)", r.Code)
+	}
+	if r.RequestId != "" {
+		s += fmt.Sprintf(" [request id %s]", r.RequestId)
+	}
+	return s
+}
+
+// Is reports whether the status of the response matches the sentinel error target.
+func (r *ErrorResponse) Is(target error) bool {
+	return statusError(r.StatusCode) == target
 }
 
 // CheckResponse checks the API response for errors, and returns them if present.
 // A response is considered an error if the status code is different than 2xx. Specific requests
 // may have additional requirements, but this is sufficient in most of the cases.
+// The returned error is always an *ErrorResponse, or a *RateLimitError wrapping
+// one, even when the body is not the JSON the API normally answers with.
 func CheckResponse(resp *http.Response) error {
 	if code := resp.StatusCode; 200 <= code && code <= 299 {
 		return nil
 	}
 
-	errorResponse := &ErrorResponse{}
-	errorResponse.HttpResponse = resp
+	errorResponse := newErrorResponse(resp)
 
-	err := json.NewDecoder(resp.Body).Decode(errorResponse)
-	if err != nil {
-		return err
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	errorResponse.Body = snippet(body)
+	// a body that is not JSON, e.g. the HTML page of a proxy, leaves Message and Code empty
+	json.Unmarshal(body, errorResponse)
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return newRateLimitError(errorResponse)
 	}
+	return errorResponse
+}
 
+func newErrorResponse(resp *http.Response) *ErrorResponse {
+	errorResponse := &ErrorResponse{
+		StatusCode: resp.StatusCode,
+		RequestId:  resp.Header.Get("X-Request-Id"),
+	}
+	errorResponse.HttpResponse = resp
+	if req := resp.Request; req != nil {
+		errorResponse.Method = req.Method
+		errorResponse.URL = req.URL.String()
+	}
 	return errorResponse
 }
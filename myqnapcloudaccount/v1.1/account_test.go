@@ -1,23 +1,19 @@
 package account_test
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/context"
-	"golang.org/x/oauth2"
 
 	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+	"github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1/accounttest"
 	"github.com/qeek-dev/qeek-api-go-client/test"
 )
 
-const (
-	// remember to change it to a valid token to run test
-	// curl to get access token
-	// curl /usr/local/bin/qcloud_auth_tool signin -i <client_id> -s <client-secret> -t password -u <user-name> -p <password>
-	AccessToken = ""
-)
-
 type AccountTestCaseSuite struct {
 	env     *test.Env
 	service *account.Service
@@ -38,21 +34,70 @@ func TestMeGetCall_Do(t *testing.T) {
 	s, teardownTestCase := setupAccountTestCase(t)
 	defer teardownTestCase(t)
 
+	srv := accounttest.NewServer()
+	defer srv.Close()
+	token := srv.AddUser(accounttest.User{
+		User: account.User{
+			PublicProfile: account.PublicProfile{UserId: "u1", DisplayName: "Alice"},
+			Email:         "alice@example.com",
+		},
+	})
+
 	tt := []struct {
 		name          string
-		wantError     string
+		wantUser      *account.User
+		wantError     error
 		setupTestCase test.SetupSubTest
 	}{
 		{
 			name: "200",
 			setupTestCase: func(t *testing.T) func(t *testing.T) {
-				ctx := context.Background()
-				ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: AccessToken})
-				tc := oauth2.NewClient(ctx, ts)
-				s.service = account.New(tc)
-
-				return func(t *testing.T) {
-				}
+				s.service = srv.Service(token)
+				return func(t *testing.T) {}
+			},
+			wantUser: &account.User{
+				PublicProfile: account.PublicProfile{UserId: "u1", DisplayName: "Alice"},
+				Email:         "alice@example.com",
+			},
+		},
+		{
+			name: "fail with invalid token",
+			setupTestCase: func(t *testing.T) func(t *testing.T) {
+				s.service = srv.Service("invalid")
+				return func(t *testing.T) {}
+			},
+			wantError: account.ErrUnauthorized,
+		},
+		{
+			name: "fail with bad gateway",
+			setupTestCase: func(t *testing.T) func(t *testing.T) {
+				s.service = srv.Service(token)
+				srv.Inject(accounttest.ServerError(http.StatusBadGateway))
+				return func(t *testing.T) { srv.ClearFaults() }
+			},
+			wantError: account.ErrServer,
+		},
+		{
+			name: "fail with rate limit",
+			setupTestCase: func(t *testing.T) func(t *testing.T) {
+				s.service = srv.Service(token)
+				srv.Inject(accounttest.RateLimited(time.Minute))
+				return func(t *testing.T) { srv.ClearFaults() }
+			},
+			wantError: account.ErrRateLimited,
+		},
+		{
+			name: "success after retrying server error",
+			setupTestCase: func(t *testing.T) func(t *testing.T) {
+				s.service = srv.Service(token, account.WithRetryPolicy(testRetryPolicy))
+				f := accounttest.ServerError(http.StatusServiceUnavailable)
+				f.Times = 1
+				srv.Inject(f)
+				return func(t *testing.T) { srv.ClearFaults() }
+			},
+			wantUser: &account.User{
+				PublicProfile: account.PublicProfile{UserId: "u1", DisplayName: "Alice"},
+				Email:         "alice@example.com",
 			},
 		},
 	}
@@ -62,17 +107,127 @@ func TestMeGetCall_Do(t *testing.T) {
 			teardownSubTest := tc.setupTestCase(t)
 			defer teardownSubTest(t)
 
-			if AccessToken == "" {
-				t.Skip("access token is empty, skip test")
-			} else {
-				res, err := s.service.Me.Get().Do()
-				if err != nil {
-					assert.Equal(t, err.Error(), tc.wantError, "An error was expected")
-				} else {
-					assert.NotNil(t, res)
-				}
+			res, err := s.service.Me.Get().Do()
+			if tc.wantError != nil {
+				assert.ErrorIs(t, err, tc.wantError)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.wantUser.PublicProfile, res.PublicProfile)
+				assert.Equal(t, tc.wantUser.Email, res.Email)
 			}
+		})
+	}
+}
 
+func TestMeGetCall_DoContext(t *testing.T) {
+	s, teardownTestCase := setupAccountTestCase(t)
+	defer teardownTestCase(t)
+
+	tt := []struct {
+		name          string
+		givenContext  func() (context.Context, context.CancelFunc)
+		wantError     error
+		setupTestCase test.SetupSubTest
+	}{
+		{
+			name: "fail with canceled context",
+			givenContext: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+			wantError: context.Canceled,
+		},
+		{
+			name: "fail with deadline exceeded",
+			givenContext: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			wantError: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			release := make(chan struct{})
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-release:
+				case <-r.Context().Done():
+				}
+			}))
+			defer ts.Close()
+			defer close(release)
+
+			s.service = account.New(ts.Client(), account.WithBaseURL(ts.URL))
+
+			ctx, cancel := tc.givenContext()
+			defer cancel()
+
+			_, err := s.service.Me.Get().Context(ctx).Do()
+			assert.ErrorIs(t, err, tc.wantError)
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tt := []struct {
+		name           string
+		givenOptions   []account.Option
+		wantBasePath   string
+		wantAPIVersion string
+	}{
+		{
+			name:           "default environment",
+			wantBasePath:   wantDefaultEnvironment.BasePath,
+			wantAPIVersion: wantDefaultEnvironment.APIVersion,
+		},
+		{
+			name:           "with alpha environment",
+			givenOptions:   []account.Option{account.WithEnvironment(account.Alpha)},
+			wantBasePath:   account.Alpha.BasePath,
+			wantAPIVersion: account.Alpha.APIVersion,
+		},
+		{
+			name:           "with dev environment",
+			givenOptions:   []account.Option{account.WithEnvironment(account.Dev)},
+			wantBasePath:   account.Dev.BasePath,
+			wantAPIVersion: account.Dev.APIVersion,
+		},
+		{
+			name:           "with base url and api version overriding environment",
+			givenOptions:   []account.Option{account.WithEnvironment(account.Dev), account.WithBaseURL("http://127.0.0.1:8080"), account.WithAPIVersion("v2")},
+			wantBasePath:   "http://127.0.0.1:8080",
+			wantAPIVersion: "v2",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s := account.New(nil, tc.givenOptions...)
+			assert.Equal(t, tc.wantBasePath, s.BasePath)
+			assert.Equal(t, tc.wantAPIVersion, s.APIVersion)
 		})
 	}
 }
+
+func TestService_APIVersionPath(t *testing.T) {
+	var gotPath string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Write([]byte(`{"message":"OK","code":0,"result":{}}`))
+	}))
+	defer ts.Close()
+
+	current := account.New(ts.Client(), account.WithBaseURL(ts.URL), account.WithAPIVersion("v1.1"))
+	next := account.New(ts.Client(), account.WithBaseURL(ts.URL), account.WithAPIVersion("v2"))
+
+	_, err := current.Me.Get().Do()
+	assert.NoError(t, err)
+	assert.Equal(t, "/v1.1/me", gotPath)
+
+	_, err = next.Me.Get().Do()
+	assert.NoError(t, err)
+	assert.Equal(t, "/v2/me", gotPath)
+}
@@ -0,0 +1,158 @@
+package accounttest_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+	"github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1/accounttest"
+)
+
+func TestRecorder(t *testing.T) {
+	srv := accounttest.NewServer()
+	token := srv.AddUser(accounttest.User{
+		User: account.User{
+			PublicProfile: account.PublicProfile{UserId: "alice", DisplayName: "Alice"},
+			Email:         "alice@example.com",
+			SimpleToken:   "simple-secret",
+		},
+		Password: "alice-password",
+	})
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	calls := func(s *account.Service) error {
+		if _, err := s.Me.Get().Do(); err != nil {
+			return err
+		}
+		if err := s.Me.Password.Change().Old("alice-password").New("new-password").Do(); err != nil {
+			return err
+		}
+		if _, err := s.Me.Avatar.Upload(strings.NewReader("\x89PNG\x00"), "image/png").Do(); err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := s.Me.Avatar.Download(account.AvatarSmall, &buf).Do(); err != nil {
+			return err
+		}
+		if buf.String() != "\x89PNG\x00" {
+			t.Errorf("got avatar %q", buf.String())
+		}
+		_, err := s.User.Get("bob").Do()
+		assert.ErrorIs(t, err, account.ErrNotFound)
+		return nil
+	}
+
+	// record against the fake server
+	rec, err := accounttest.NewRecorder(path, accounttest.ModeRecord, srv.Client().Transport)
+	if !assert.NoError(t, err) {
+		return
+	}
+	client := &http.Client{Transport: &oauth2.Transport{
+		Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}),
+		Base:   rec,
+	}}
+	assert.NoError(t, calls(account.New(client, account.WithBaseURL(srv.URL))))
+	assert.NoError(t, rec.Save())
+	srv.Close()
+
+	b, err := os.ReadFile(path)
+	if assert.NoError(t, err) {
+		for _, secret := range []string{token, "alice-password", "new-password", "simple-secret"} {
+			assert.NotContains(t, string(b), secret)
+		}
+	}
+
+	// replay without the server
+	rep, err := accounttest.NewRecorder(path, accounttest.ModeReplay, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	s := account.New(rep.Client(), account.WithBaseURL("http://replay.invalid"))
+	assert.NoError(t, calls(s))
+
+	tt := []struct {
+		name      string
+		givenCall func() error
+	}{
+		{
+			name:      "interaction already replayed",
+			givenCall: func() error { _, err := s.Me.Get().Do(); return err },
+		},
+		{
+			name:      "different body",
+			givenCall: func() error { return s.Me.Password.RequestReset().Email("alice@example.com").Do() },
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorIs(t, tc.givenCall(), accounttest.ErrNoInteraction)
+		})
+	}
+}
+
+// closeRecorder records whether a request body was closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestRecorder_Form(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"token-secret","token_type":"bearer"}`))
+	}))
+	defer ts.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	form := url.Values{"grant_type": {"password"}, "username": {"gary"}, "password": {"password-secret"}, "client_secret": {"client-secret"}}
+
+	// record a token request
+	rec, err := accounttest.NewRecorder(path, accounttest.ModeRecord, ts.Client().Transport)
+	if !assert.NoError(t, err) {
+		return
+	}
+	resp, err := rec.Client().PostForm(ts.URL+"/oauth/token", form)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+	assert.NoError(t, rec.Save())
+
+	b, err := os.ReadFile(path)
+	if assert.NoError(t, err) {
+		for _, secret := range []string{"password-secret", "client-secret", "token-secret"} {
+			assert.NotContains(t, string(b), secret)
+		}
+	}
+
+	// replay it, closing the request body
+	rep, err := accounttest.NewRecorder(path, accounttest.ModeReplay, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	body := &closeRecorder{Reader: strings.NewReader(form.Encode())}
+	req, _ := http.NewRequest("POST", "http://replay.invalid/oauth/token", body)
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader(form.Encode())), nil }
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err = rep.RoundTrip(req)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.True(t, body.closed, "the request body was expected to be closed")
+}
@@ -0,0 +1,120 @@
+package accounttest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fault is a failure injected into the replies of the Server.
+type Fault struct {
+	Method string // method of the affected requests, any when empty
+	Path   string // path prefix of the affected requests, e.g. "/v1.1/me", any when empty
+	Times  int    // number of requests affected, all when 0
+
+	Latency time.Duration // delay before replying
+	Status  int           // status replied instead of serving the request, when not 0
+	Header  http.Header   // headers of the reply
+	Body    string        // body of the reply
+}
+
+// Latency delays the replies by d.
+func Latency(d time.Duration) Fault {
+	return Fault{Latency: d}
+}
+
+// ServerError replies with status, e.g. http.StatusBadGateway, and an HTML
+// body as a failing proxy would.
+func ServerError(status int) Fault {
+	return Fault{
+		Status: status,
+		Header: http.Header{"Content-Type": {"text/html"}},
+		Body:   "<html><body><h1>" + strconv.Itoa(status) + " " + http.StatusText(status) + "</h1></body></html>",
+	}
+}
+
+// RateLimited replies with 429 Too Many Requests and rate limit headers
+// announcing a reset after retryAfter.
+func RateLimited(retryAfter time.Duration) Fault {
+	seconds := int(retryAfter.Round(time.Second) / time.Second)
+	return Fault{
+		Status: http.StatusTooManyRequests,
+		Header: http.Header{
+			"Content-Type":          {"application/json"},
+			"Retry-After":           {strconv.Itoa(seconds)},
+			"X-Ratelimit-Limit":     {"60"},
+			"X-Ratelimit-Remaining": {"0"},
+			"X-Ratelimit-Reset":     {strconv.FormatInt(time.Now().Add(retryAfter).Unix(), 10)},
+		},
+		Body: `{"message":"too many requests","code":42900}`,
+	}
+}
+
+// Malformed replies with 200 OK and a truncated JSON body.
+func Malformed() Fault {
+	return Fault{
+		Status: http.StatusOK,
+		Header: http.Header{"Content-Type": {"application/json"}},
+		Body:   `{"message":"OK","code":0,"result":{"user_id":`,
+	}
+}
+
+// Inject adds f to the faults of the Server. The first matching fault
+// applies to a request.
+func (s *Server) Inject(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all faults of the Server.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// fault applies the first fault matching r and reports whether it replied.
+func (s *Server) fault(w http.ResponseWriter, r *http.Request) bool {
+	f := s.takeFault(r)
+	if f == nil {
+		return false
+	}
+	if f.Latency > 0 {
+		select {
+		case <-time.After(f.Latency):
+		case <-r.Context().Done():
+			return true
+		}
+	}
+	if f.Status == 0 {
+		return false
+	}
+	for k, v := range f.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(f.Status)
+	w.Write([]byte(f.Body))
+	return true
+}
+
+func (s *Server) takeFault(r *http.Request) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, f := range s.faults {
+		if f.Method != "" && f.Method != r.Method || !strings.HasPrefix(r.URL.Path, f.Path) {
+			continue
+		}
+		if f.Times > 0 {
+			if f.Times--; f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
@@ -0,0 +1,503 @@
+package accounttest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+)
+
+// Codes reported by the Server besides the ones defined by the account
+// package. The real codes are not documented; the values are assumed to be
+// the HTTP status followed by two digits and may be reassigned.
+var (
+	ErrorCodeBadRequest   account.ErrorCode = 40000
+	ErrorCodeUnauthorized account.ErrorCode = 40100
+	ErrorCodeNotFound     account.ErrorCode = 40400
+	ErrorCodeConflict     account.ErrorCode = 40900
+	ErrorCodeServerError  account.ErrorCode = 50000
+)
+
+// minPasswordLength is the length below which passwords are rejected as weak.
+const minPasswordLength = 8
+
+const defaultPageSize = 50
+
+// errorBody is the body of error replies.
+type errorBody struct {
+	Message string            `json:"message"`
+	Code    account.ErrorCode `json:"code"`
+}
+
+// apiError is an error reply of a handler.
+type apiError struct {
+	status  int
+	code    account.ErrorCode
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func errorf(status int, code account.ErrorCode, message string) *apiError {
+	return &apiError{status, code, message}
+}
+
+// rawBody is a result written as is instead of in an envelope.
+type rawBody struct {
+	contentType string
+	data        []byte
+}
+
+// handlerFunc serves an API request of the user u, nil for unauthenticated
+// routes, and returns the result to wrap in the response envelope.
+type handlerFunc func(r *http.Request, u *User) (interface{}, error)
+
+type route struct {
+	pattern string
+	public  bool
+	h       handlerFunc
+}
+
+func (s *Server) routes() []route {
+	api := func(method, path string) string {
+		return method + " /" + APIVersion + "/" + path
+	}
+	return []route{
+		{api("GET", "me"), false, s.getMe},
+		{api("PATCH", "me"), false, s.updateMe},
+		{api("GET", "me/activities"), false, s.listActivities},
+		{api("PUT", "me/password"), false, s.changePassword},
+		{api("POST", "password/reset"), true, s.requestReset},
+		{api("POST", "password/reset/confirm"), true, s.confirmReset},
+		{api("PUT", "me/avatar"), false, s.uploadAvatar},
+		{api("GET", "me/avatar"), false, s.downloadAvatar},
+		{api("DELETE", "me/avatar"), false, s.deleteAvatar},
+		{api("GET", "friends"), false, s.listFriends},
+		{api("POST", "friends"), false, s.inviteFriend},
+		{api("POST", "friends/{user_id}/accept"), false, s.acceptFriend},
+		{api("DELETE", "friends/{user_id}"), false, s.removeFriend},
+		{api("GET", "users/{user_id}"), false, s.getUser},
+		{api("GET", "users"), false, s.findUser},
+
+		// avatar URLs returned by uploads, public like the CDN serving them
+		{"GET /avatars/{user_id}/{size}", true, s.serveAvatar},
+		{"GET /avatars/cn/{user_id}/{size}", true, s.serveAvatar},
+	}
+}
+
+func (s *Server) newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, rt := range s.routes() {
+		rt := rt
+		mux.HandleFunc(rt.pattern, func(w http.ResponseWriter, r *http.Request) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			var u *User
+			if !rt.public {
+				if u = s.authenticate(r); u == nil {
+					writeError(w, errorf(http.StatusUnauthorized, ErrorCodeUnauthorized, "invalid access token"))
+					return
+				}
+			}
+			result, err := rt.h(r, u)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			if raw, ok := result.(rawBody); ok {
+				w.Header().Set("Content-Type", raw.contentType)
+				w.Write(raw.data)
+				return
+			}
+			writeJSON(w, http.StatusOK, account.Envelope[interface{}]{Message: "OK", Code: account.CodeOK, Result: result})
+		})
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, errorf(http.StatusNotFound, ErrorCodeNotFound, "no such endpoint"))
+	})
+	return mux
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.fault(w, r) {
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) authenticate(r *http.Request) *User {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil
+	}
+	id, ok := s.tokens[token]
+	if !ok {
+		return nil
+	}
+	return s.users[id]
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	e, ok := err.(*apiError)
+	if !ok {
+		e = errorf(http.StatusInternalServerError, ErrorCodeServerError, err.Error())
+	}
+	writeJSON(w, e.status, errorBody{Message: e.message, Code: e.code})
+}
+
+func decode(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errorf(http.StatusBadRequest, ErrorCodeBadRequest, "malformed request body")
+	}
+	return nil
+}
+
+// page returns the part of n items selected by the page_size and page_token
+// parameters of r, and the token of the next page.
+func page(r *http.Request, n int) (start, end int, next string, err error) {
+	size := defaultPageSize
+	if v := r.URL.Query().Get("page_size"); v != "" {
+		if size, err = strconv.Atoi(v); err != nil || size <= 0 {
+			return 0, 0, "", errorf(http.StatusBadRequest, ErrorCodeBadRequest, "invalid page_size")
+		}
+	}
+	if v := r.URL.Query().Get("page_token"); v != "" {
+		if start, err = strconv.Atoi(v); err != nil || start < 0 || start > n {
+			return 0, 0, "", errorf(http.StatusBadRequest, ErrorCodeBadRequest, "invalid page_token")
+		}
+	}
+	end = start + size
+	if end < n {
+		next = strconv.Itoa(end)
+	} else {
+		end = n
+	}
+	return start, end, next, nil
+}
+
+func (s *Server) getMe(r *http.Request, u *User) (interface{}, error) {
+	return u.User, nil
+}
+
+func (s *Server) updateMe(r *http.Request, u *User) (interface{}, error) {
+	var fields map[string]json.RawMessage
+	if err := decode(r, &fields); err != nil {
+		return nil, err
+	}
+	updatable := map[string]bool{
+		"display_name": true, "first_name": true, "last_name": true, "language": true, "gender": true,
+		"brithday": true, "mobile_number": true, "subscribed": true, "portal_notify": true,
+	}
+	if len(fields) == 0 {
+		return nil, errorf(http.StatusBadRequest, ErrorCodeBadRequest, "no fields to update")
+	}
+	for k := range fields {
+		if !updatable[k] {
+			return nil, errorf(http.StatusBadRequest, ErrorCodeBadRequest, "field "+k+" cannot be updated")
+		}
+	}
+
+	// overlay the fields on the JSON form of the user
+	b, _ := json.Marshal(u.User)
+	var cur map[string]json.RawMessage
+	json.Unmarshal(b, &cur)
+	for k, v := range fields {
+		cur[k] = v
+	}
+	b, _ = json.Marshal(cur)
+	updated := u.User
+	if err := json.Unmarshal(b, &updated); err != nil {
+		return nil, errorf(http.StatusBadRequest, ErrorCodeBadRequest, "invalid field value")
+	}
+	updated.UpdatedAt = now()
+	u.User = updated
+	s.addActivity(u.UserId, account.Activity{EventType: account.ActivityProfileUpdated})
+	return u.User, nil
+}
+
+func (s *Server) listActivities(r *http.Request, u *User) (interface{}, error) {
+	q := r.URL.Query()
+	var since, until time.Time
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"since", &since}, {"until", &until}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, errorf(http.StatusBadRequest, ErrorCodeBadRequest, "invalid "+p.name)
+			}
+			*p.t = t
+		}
+	}
+	types := map[string]bool{}
+	if v := q.Get("event_type"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			types[t] = true
+		}
+	}
+
+	var matched []account.Activity
+	for _, a := range s.activities[u.UserId] {
+		created, _ := time.Parse(time.RFC3339, a.CreatedAt)
+		if !since.IsZero() && created.Before(since) || !until.IsZero() && !created.Before(until) {
+			continue
+		}
+		if len(types) > 0 && !types[a.EventType] {
+			continue
+		}
+		matched = append(matched, a)
+	}
+	start, end, next, err := page(r, len(matched))
+	if err != nil {
+		return nil, err
+	}
+	return account.ActivityPage{Activities: append([]account.Activity{}, matched[start:end]...), NextPageToken: next}, nil
+}
+
+func (s *Server) changePassword(r *http.Request, u *User) (interface{}, error) {
+	var payload struct {
+		Old string `json:"old_password"`
+		New string `json:"new_password"`
+	}
+	if err := decode(r, &payload); err != nil {
+		return nil, err
+	}
+	if payload.Old != u.Password {
+		return nil, errorf(http.StatusBadRequest, account.ErrorCodeWrongPassword, "wrong password")
+	}
+	if len(payload.New) < minPasswordLength {
+		return nil, errorf(http.StatusBadRequest, account.ErrorCodeWeakPassword, "password is too weak")
+	}
+	u.Password = payload.New
+	s.addActivity(u.UserId, account.Activity{EventType: account.ActivityPasswordChanged})
+	return struct{}{}, nil
+}
+
+func (s *Server) requestReset(r *http.Request, _ *User) (interface{}, error) {
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if err := decode(r, &payload); err != nil {
+		return nil, err
+	}
+	// unknown addresses are not reported, not to disclose which ones exist
+	if u := s.userByEmail(payload.Email); u != nil {
+		for token, id := range s.resetTokens {
+			if id == u.UserId {
+				delete(s.resetTokens, token)
+			}
+		}
+		s.resetTokens[s.nextID("reset")] = u.UserId
+	}
+	return struct{}{}, nil
+}
+
+func (s *Server) confirmReset(r *http.Request, _ *User) (interface{}, error) {
+	var payload struct {
+		Token string `json:"token"`
+		New   string `json:"new_password"`
+	}
+	if err := decode(r, &payload); err != nil {
+		return nil, err
+	}
+	id, ok := s.resetTokens[payload.Token]
+	if !ok {
+		return nil, errorf(http.StatusBadRequest, account.ErrorCodeInvalidResetToken, "invalid reset token")
+	}
+	if len(payload.New) < minPasswordLength {
+		return nil, errorf(http.StatusBadRequest, account.ErrorCodeWeakPassword, "password is too weak")
+	}
+	delete(s.resetTokens, payload.Token)
+	s.users[id].Password = payload.New
+	s.addActivity(id, account.Activity{EventType: account.ActivityPasswordReset})
+	return struct{}{}, nil
+}
+
+func (s *Server) avatarURLs(userId string) account.Avatars {
+	if _, ok := s.avatars[userId]; !ok {
+		return account.Avatars{}
+	}
+	url := func(region, size string) string {
+		return s.URL + "/avatars/" + region + userId + "/" + size
+	}
+	return account.Avatars{
+		Small:  url("", "small"),
+		Medium: url("", "medium"),
+		Icon:   url("", "icon"),
+		Cn: account.AvatarUrls{
+			Small:  url("cn/", "small"),
+			Medium: url("cn/", "medium"),
+			Icon:   url("cn/", "icon"),
+		},
+	}
+}
+
+func (s *Server) uploadAvatar(r *http.Request, u *User) (interface{}, error) {
+	f, h, err := r.FormFile("avatar")
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, ErrorCodeBadRequest, "missing avatar file")
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	contentType := h.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, errorf(http.StatusBadRequest, ErrorCodeBadRequest, "avatar is not an image")
+	}
+	s.avatars[u.UserId] = avatar{contentType: contentType, data: data}
+	u.Avatars = s.avatarURLs(u.UserId)
+	return u.Avatars, nil
+}
+
+func (s *Server) downloadAvatar(r *http.Request, u *User) (interface{}, error) {
+	switch account.AvatarSize(r.URL.Query().Get("size")) {
+	case account.AvatarSmall, account.AvatarMedium, account.AvatarIcon:
+	default:
+		return nil, errorf(http.StatusBadRequest, ErrorCodeBadRequest, "invalid size")
+	}
+	a, ok := s.avatars[u.UserId]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, ErrorCodeNotFound, "no avatar")
+	}
+	return rawBody{a.contentType, a.data}, nil
+}
+
+func (s *Server) serveAvatar(r *http.Request, _ *User) (interface{}, error) {
+	switch account.AvatarSize(r.PathValue("size")) {
+	case account.AvatarSmall, account.AvatarMedium, account.AvatarIcon:
+	default:
+		return nil, errorf(http.StatusNotFound, ErrorCodeNotFound, "no avatar")
+	}
+	a, ok := s.avatars[r.PathValue("user_id")]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, ErrorCodeNotFound, "no avatar")
+	}
+	return rawBody{a.contentType, a.data}, nil
+}
+
+func (s *Server) deleteAvatar(r *http.Request, u *User) (interface{}, error) {
+	delete(s.avatars, u.UserId)
+	u.Avatars = account.Avatars{}
+	return struct{}{}, nil
+}
+
+func (s *Server) friend(userId, friendId string) account.Friend {
+	f := s.friends[userId][friendId]
+	other := s.users[friendId]
+	return account.Friend{
+		UserId:      friendId,
+		Email:       other.Email,
+		DisplayName: other.DisplayName,
+		Status:      f.status,
+		Avatars:     other.Avatars,
+		CreatedAt:   f.createdAt,
+		UpdatedAt:   f.updatedAt,
+	}
+}
+
+func (s *Server) listFriends(r *http.Request, u *User) (interface{}, error) {
+	status := r.URL.Query().Get("status")
+	ids := make([]string, 0, len(s.friends[u.UserId]))
+	for id, f := range s.friends[u.UserId] {
+		if status == "" || f.status == status {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	start, end, next, err := page(r, len(ids))
+	if err != nil {
+		return nil, err
+	}
+	friends := make([]account.Friend, 0, end-start)
+	for _, id := range ids[start:end] {
+		friends = append(friends, s.friend(u.UserId, id))
+	}
+	return account.FriendPage{Friends: friends, NextPageToken: next}, nil
+}
+
+func (s *Server) inviteFriend(r *http.Request, u *User) (interface{}, error) {
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if err := decode(r, &payload); err != nil {
+		return nil, err
+	}
+	other := s.userByEmail(payload.Email)
+	if other == nil {
+		return nil, errorf(http.StatusNotFound, ErrorCodeNotFound, "user not found")
+	}
+	if other.UserId == u.UserId {
+		return nil, errorf(http.StatusBadRequest, ErrorCodeBadRequest, "cannot invite yourself")
+	}
+	if _, ok := s.friends[u.UserId][other.UserId]; ok {
+		return nil, errorf(http.StatusConflict, ErrorCodeConflict, "already a friend or invited")
+	}
+	s.setFriend(u.UserId, other.UserId, account.FriendStatusPending)
+	return s.friend(u.UserId, other.UserId), nil
+}
+
+func (s *Server) acceptFriend(r *http.Request, u *User) (interface{}, error) {
+	id := r.PathValue("user_id")
+	f, ok := s.friends[u.UserId][id]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, ErrorCodeNotFound, "friend not found")
+	}
+	if f.status != account.FriendStatusInvited {
+		return nil, errorf(http.StatusConflict, ErrorCodeConflict, "no invitation to accept")
+	}
+	s.setFriend(u.UserId, id, account.FriendStatusAccepted)
+	return s.friend(u.UserId, id), nil
+}
+
+func (s *Server) removeFriend(r *http.Request, u *User) (interface{}, error) {
+	id := r.PathValue("user_id")
+	if _, ok := s.friends[u.UserId][id]; !ok {
+		return nil, errorf(http.StatusNotFound, ErrorCodeNotFound, "friend not found")
+	}
+	delete(s.friends[u.UserId], id)
+	delete(s.friends[id], u.UserId)
+	return struct{}{}, nil
+}
+
+func (s *Server) getUser(r *http.Request, _ *User) (interface{}, error) {
+	other, ok := s.users[r.PathValue("user_id")]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, ErrorCodeNotFound, "user not found")
+	}
+	return other.PublicProfile, nil
+}
+
+func (s *Server) findUser(r *http.Request, _ *User) (interface{}, error) {
+	other := s.userByEmail(r.URL.Query().Get("email"))
+	if other == nil {
+		return nil, errorf(http.StatusNotFound, ErrorCodeNotFound, "user not found")
+	}
+	return other.PublicProfile, nil
+}
+
+func (s *Server) userByEmail(email string) *User {
+	for _, u := range s.users {
+		if email != "" && strings.EqualFold(u.Email, email) {
+			return u
+		}
+	}
+	return nil
+}
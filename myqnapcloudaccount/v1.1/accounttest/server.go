@@ -0,0 +1,208 @@
+// Package accounttest provides an in-process fake of the myQNAPcloud account
+// API for tests.
+//
+// A Server keeps users, friends, activities and avatars in memory, checks the
+// bearer token of every request and can inject faults:
+//
+//	srv := accounttest.NewServer()
+//	defer srv.Close()
+//	token := srv.AddUser(accounttest.User{User: account.User{Email: "a@example.com"}, Password: "secret-password"})
+//	s := srv.Service(token)
+//	me, err := s.Me.Get().Do()
+package accounttest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+)
+
+// APIVersion is the API version served by the Server.
+const APIVersion = "v1.1"
+
+// User is an account seeded into the Server.
+type User struct {
+	account.User
+	Password string
+	// Token is the access token of the user, generated by AddUser when empty.
+	Token string
+}
+
+type avatar struct {
+	contentType string
+	data        []byte
+}
+
+// Server is a fake myQNAPcloud account API listening on a local address.
+// It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	users       map[string]*User                  // by user id
+	tokens      map[string]string                 // access token to user id
+	friends     map[string]map[string]*friendship // user id to friend id
+	activities  map[string][]account.Activity
+	avatars     map[string]avatar
+	resetTokens map[string]string // reset token to user id
+	faults      []*Fault
+	seq         int
+	mux         *http.ServeMux
+}
+
+type friendship struct {
+	status    string
+	createdAt string
+	updatedAt string
+}
+
+// NewServer starts and returns a new Server. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		users:       map[string]*User{},
+		tokens:      map[string]string{},
+		friends:     map[string]map[string]*friendship{},
+		activities:  map[string][]account.Activity{},
+		avatars:     map[string]avatar{},
+		resetTokens: map[string]string{},
+	}
+	s.mux = s.newMux()
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Service returns an account.Service calling the Server with token as the
+// bearer token. opts are applied after the base URL is set.
+func (s *Server) Service(token string, opts ...account.Option) *account.Service {
+	client := &http.Client{
+		Transport: &oauth2.Transport{
+			Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}),
+			Base:   s.Server.Client().Transport,
+		},
+	}
+	opts = append([]account.Option{account.WithBaseURL(s.URL), account.WithAPIVersion(APIVersion)}, opts...)
+	return account.New(client, opts...)
+}
+
+// AddUser seeds u and returns its access token. A missing UserId and Token
+// are generated.
+func (s *Server) AddUser(u User) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u.UserId == "" {
+		u.UserId = s.nextID("user")
+	}
+	if u.Token == "" {
+		u.Token = s.nextID("token")
+	}
+	if u.CreatedAt == "" {
+		u.CreatedAt = now()
+		u.UpdatedAt = u.CreatedAt
+	}
+	s.users[u.UserId] = &u
+	s.tokens[u.Token] = u.UserId
+	return u.Token
+}
+
+// User returns the current state of the user with the given id.
+func (s *Server) User(userId string) (User, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[userId]
+	if !ok {
+		return User{}, false
+	}
+	return *u, true
+}
+
+// AddFriend makes the users a and b friends with each other, status being
+// the status seen by a: FriendStatusPending when a invited b,
+// FriendStatusInvited when b invited a, or FriendStatusAccepted.
+func (s *Server) AddFriend(a, b, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setFriend(a, b, status)
+}
+
+func (s *Server) setFriend(a, b, status string) {
+	reverse := status
+	switch status {
+	case account.FriendStatusPending:
+		reverse = account.FriendStatusInvited
+	case account.FriendStatusInvited:
+		reverse = account.FriendStatusPending
+	}
+	t := now()
+	for _, f := range []struct{ from, to, status string }{{a, b, status}, {b, a, reverse}} {
+		if s.friends[f.from] == nil {
+			s.friends[f.from] = map[string]*friendship{}
+		}
+		if cur, ok := s.friends[f.from][f.to]; ok {
+			cur.status, cur.updatedAt = f.status, t
+		} else {
+			s.friends[f.from][f.to] = &friendship{status: f.status, createdAt: t, updatedAt: t}
+		}
+	}
+}
+
+// AddActivity appends activities to the activity log of the user. A missing
+// Id and CreatedAt are generated.
+func (s *Server) AddActivity(userId string, activities ...account.Activity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.addActivity(userId, activities...)
+}
+
+func (s *Server) addActivity(userId string, activities ...account.Activity) {
+	for _, a := range activities {
+		if a.Id == "" {
+			a.Id = s.nextID("activity")
+		}
+		if a.CreatedAt == "" {
+			a.CreatedAt = now()
+		}
+		s.activities[userId] = append(s.activities[userId], a)
+	}
+}
+
+// Avatar returns the avatar uploaded by the user.
+func (s *Server) Avatar(userId string) (data []byte, contentType string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.avatars[userId]
+	return a.data, a.contentType, ok
+}
+
+// ResetToken returns the token of the last password reset requested for the
+// email address, as it would be sent by mail.
+func (s *Server) ResetToken(email string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for token, id := range s.resetTokens {
+		if s.users[id].Email == email {
+			return token, true
+		}
+	}
+	return "", false
+}
+
+func (s *Server) nextID(prefix string) string {
+	s.seq++
+	return fmt.Sprintf("%s-%d", prefix, s.seq)
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
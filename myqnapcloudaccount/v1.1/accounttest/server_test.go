@@ -0,0 +1,290 @@
+package accounttest_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+	"github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1/accounttest"
+)
+
+type ServerTestCaseSuite struct {
+	srv        *accounttest.Server
+	alice, bob *account.Service
+}
+
+func setupServerTestCase(t *testing.T) (ServerTestCaseSuite, func(t *testing.T)) {
+	s := ServerTestCaseSuite{srv: accounttest.NewServer()}
+	s.alice = s.srv.Service(s.srv.AddUser(accounttest.User{
+		User: account.User{
+			PublicProfile: account.PublicProfile{UserId: "alice", DisplayName: "Alice"},
+			Email:         "alice@example.com",
+		},
+		Password: "alice-password",
+	}))
+	s.bob = s.srv.Service(s.srv.AddUser(accounttest.User{
+		User: account.User{
+			PublicProfile: account.PublicProfile{UserId: "bob", DisplayName: "Bob"},
+			Email:         "bob@example.com",
+		},
+		Password: "bob-password",
+	}))
+
+	return s, func(t *testing.T) {
+		s.srv.Close()
+	}
+}
+
+func TestServer_Me(t *testing.T) {
+	s, teardownTestCase := setupServerTestCase(t)
+	defer teardownTestCase(t)
+
+	me, err := s.alice.Me.Update().FirstName("Alice").Subscribed(true).Do()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Alice", me.FirstName)
+		assert.True(t, me.Subscribed)
+		assert.Equal(t, "alice@example.com", me.Email)
+	}
+
+	got, _ := s.srv.User("alice")
+	assert.Equal(t, "Alice", got.FirstName)
+
+	page, err := s.alice.Me.Activity.List().EventType(account.ActivityProfileUpdated).Do()
+	if assert.NoError(t, err) && assert.Len(t, page.Activities, 1) {
+		assert.Equal(t, account.ActivityProfileUpdated, page.Activities[0].EventType)
+	}
+}
+
+func TestServer_Password(t *testing.T) {
+	s, teardownTestCase := setupServerTestCase(t)
+	defer teardownTestCase(t)
+
+	tt := []struct {
+		name     string
+		givenOld string
+		givenNew string
+		wantCode account.ErrorCode
+	}{
+		{
+			name:     "wrong old password",
+			givenOld: "wrong",
+			givenNew: "new-password",
+			wantCode: account.ErrorCodeWrongPassword,
+		},
+		{
+			name:     "weak new password",
+			givenOld: "alice-password",
+			givenNew: "weak",
+			wantCode: account.ErrorCodeWeakPassword,
+		},
+		{
+			name:     "success",
+			givenOld: "alice-password",
+			givenNew: "new-password",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := s.alice.Me.Password.Change().Old(tc.givenOld).New(tc.givenNew).Do()
+			var e *account.ErrorResponse
+			if tc.wantCode != account.CodeOK {
+				if assert.True(t, errors.As(err, &e)) {
+					assert.Equal(t, tc.wantCode, e.Code)
+				}
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	assert.NoError(t, s.alice.Me.Password.RequestReset().Email("alice@example.com").Do())
+	token, ok := s.srv.ResetToken("alice@example.com")
+	assert.True(t, ok)
+
+	err := s.alice.Me.Password.ConfirmReset().Token(token + "x").New("reset-password").Do()
+	var e *account.ErrorResponse
+	if assert.True(t, errors.As(err, &e)) {
+		assert.Equal(t, account.ErrorCodeInvalidResetToken, e.Code)
+	}
+	assert.NoError(t, s.alice.Me.Password.ConfirmReset().Token(token).New("reset-password").Do())
+
+	got, _ := s.srv.User("alice")
+	assert.Equal(t, "reset-password", got.Password)
+}
+
+func TestServer_Avatar(t *testing.T) {
+	s, teardownTestCase := setupServerTestCase(t)
+	defer teardownTestCase(t)
+
+	avatars, err := s.alice.Me.Avatar.Upload(strings.NewReader("png-data"), "image/png").Do()
+	if assert.NoError(t, err) {
+		for _, u := range []string{avatars.Small, avatars.Medium, avatars.Icon, avatars.Cn.Small, avatars.Cn.Medium, avatars.Cn.Icon} {
+			resp, err := http.Get(u)
+			if assert.NoError(t, err, u) {
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				assert.Equal(t, http.StatusOK, resp.StatusCode, u)
+				assert.Equal(t, "image/png", resp.Header.Get("Content-Type"), u)
+				assert.Equal(t, "png-data", string(body), u)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, s.alice.Me.Avatar.Download(account.AvatarSmall, &buf).Do())
+	assert.Equal(t, "png-data", buf.String())
+
+	assert.NoError(t, s.alice.Me.Avatar.Delete().Do())
+	_, _, ok := s.srv.Avatar("alice")
+	assert.False(t, ok)
+	if avatars != nil {
+		resp, err := http.Get(avatars.Small)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		}
+	}
+	assert.ErrorIs(t, s.alice.Me.Avatar.Download(account.AvatarSmall, &buf).Do(), account.ErrNotFound)
+}
+
+func TestServer_Friends(t *testing.T) {
+	s, teardownTestCase := setupServerTestCase(t)
+	defer teardownTestCase(t)
+
+	f, err := s.alice.Friend.Invite("bob@example.com").Do()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "bob", f.UserId)
+		assert.Equal(t, account.FriendStatusPending, f.Status)
+	}
+	_, err = s.alice.Friend.Invite("bob@example.com").Do()
+	assert.ErrorIs(t, err, account.ErrConflict)
+
+	page, err := s.bob.Friend.List().Status(account.FriendStatusInvited).Do()
+	if assert.NoError(t, err) && assert.Len(t, page.Friends, 1) {
+		assert.Equal(t, "alice", page.Friends[0].UserId)
+	}
+
+	f, err = s.bob.Friend.Accept("alice").Do()
+	if assert.NoError(t, err) {
+		assert.Equal(t, account.FriendStatusAccepted, f.Status)
+	}
+
+	assert.NoError(t, s.alice.Friend.Remove("bob").Do())
+	page, err = s.bob.Friend.List().Do()
+	if assert.NoError(t, err) {
+		assert.Empty(t, page.Friends)
+	}
+}
+
+func TestServer_Users(t *testing.T) {
+	s, teardownTestCase := setupServerTestCase(t)
+	defer teardownTestCase(t)
+
+	p, err := s.alice.User.Get("bob").Do()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Bob", p.DisplayName)
+	}
+	p, err = s.alice.User.FindByEmail("BOB@example.com").Do()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "bob", p.UserId)
+	}
+	_, err = s.alice.User.Get("carol").Do()
+	assert.ErrorIs(t, err, account.ErrNotFound)
+}
+
+func TestServer_ActivityPages(t *testing.T) {
+	s, teardownTestCase := setupServerTestCase(t)
+	defer teardownTestCase(t)
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		s.srv.AddActivity("alice", account.Activity{
+			EventType: account.ActivitySignIn,
+			CreatedAt: base.Add(time.Duration(i) * time.Hour).Format(time.RFC3339),
+		})
+	}
+
+	var got []string
+	err := s.alice.Me.Activity.List().Since(base.Add(time.Hour)).PageSize(2).Pages(context.Background(), func(p *account.ActivityPage) error {
+		for _, a := range p.Activities {
+			got = append(got, a.CreatedAt)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, got, 4)
+}
+
+func TestServer_Faults(t *testing.T) {
+	s, teardownTestCase := setupServerTestCase(t)
+	defer teardownTestCase(t)
+
+	tt := []struct {
+		name      string
+		givenCtx  func() (context.Context, context.CancelFunc)
+		givenFlt  accounttest.Fault
+		wantError func(t *testing.T, err error)
+	}{
+		{
+			name: "latency",
+			givenCtx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			givenFlt: accounttest.Latency(time.Second),
+			wantError: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+			},
+		},
+		{
+			name:     "malformed body",
+			givenCtx: func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			givenFlt: accounttest.Malformed(),
+			wantError: func(t *testing.T, err error) {
+				assert.Error(t, err)
+			},
+		},
+		{
+			name:     "rate limited",
+			givenCtx: func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			givenFlt: accounttest.RateLimited(30 * time.Second),
+			wantError: func(t *testing.T, err error) {
+				var e *account.RateLimitError
+				if assert.True(t, errors.As(err, &e)) {
+					assert.Equal(t, 0, e.Remaining)
+					assert.WithinDuration(t, time.Now().Add(30*time.Second), e.Reset, 2*time.Second)
+				}
+			},
+		},
+		{
+			name:     "only matching path",
+			givenCtx: func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			givenFlt: accounttest.Fault{Path: "/v1.1/friends", Status: http.StatusInternalServerError},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			s.srv.Inject(tc.givenFlt)
+			defer s.srv.ClearFaults()
+
+			ctx, cancel := tc.givenCtx()
+			defer cancel()
+
+			_, err := s.alice.Me.Get().Context(ctx).Do()
+			if tc.wantError == nil {
+				assert.NoError(t, err)
+				return
+			}
+			tc.wantError(t, err)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package account
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Activity event types reported by the account activity log.
+const (
+	ActivitySignIn          = "sign_in"
+	ActivitySignInFailed    = "sign_in_failed"
+	ActivitySignOut         = "sign_out"
+	ActivityPasswordChanged = "password_changed"
+	ActivityPasswordReset   = "password_reset"
+	ActivityProfileUpdated  = "profile_updated"
+)
+
+type Activity struct {
+	Id        string `json:"id"`
+	EventType string `json:"event_type"`
+	Ip        string `json:"ip"`
+	Location  string `json:"location"`
+	UserAgent string `json:"user_agent"`
+	ClientId  string `json:"client_id"`
+	CreatedAt string `json:"created_at"`
+}
+
+type ActivityPage struct {
+	Activities    []Activity `json:"activities"`
+	NextPageToken string     `json:"next_page_token"`
+}
+
+type ActivityService struct {
+	s *Service
+}
+
+func NewActivityService(s *Service) *ActivityService {
+	rs := &ActivityService{s: s}
+	return rs
+}
+
+type ActivityListCall struct {
+	s      *Service
+	ctx    context.Context
+	params url.Values
+}
+
+// List returns the sign-in and security events of the current user, newest first.
+func (r *ActivityService) List() *ActivityListCall {
+	c := &ActivityListCall{s: r.s, params: url.Values{}}
+	return c
+}
+
+// Since limits the results to events at or after t.
+func (c *ActivityListCall) Since(t time.Time) *ActivityListCall {
+	c.params.Set("since", t.UTC().Format(time.RFC3339))
+	return c
+}
+
+// Until limits the results to events before t.
+func (c *ActivityListCall) Until(t time.Time) *ActivityListCall {
+	c.params.Set("until", t.UTC().Format(time.RFC3339))
+	return c
+}
+
+// EventType limits the results to the given event types, e.g. ActivitySignIn.
+func (c *ActivityListCall) EventType(eventTypes ...string) *ActivityListCall {
+	c.params.Set("event_type", strings.Join(eventTypes, ","))
+	return c
+}
+
+// PageSize sets the maximum number of events returned per page.
+func (c *ActivityListCall) PageSize(pageSize int) *ActivityListCall {
+	c.params.Set("page_size", strconv.Itoa(pageSize))
+	return c
+}
+
+// PageToken sets the page to retrieve, as returned in ActivityPage.NextPageToken.
+func (c *ActivityListCall) PageToken(pageToken string) *ActivityListCall {
+	c.params.Set("page_token", pageToken)
+	return c
+}
+
+// Context sets the context to be used in this call's Do method.
+// Any pending HTTP request will be aborted if the provided context is canceled.
+func (c *ActivityListCall) Context(ctx context.Context) *ActivityListCall {
+	c.ctx = ctx
+	return c
+}
+
+func (c *ActivityListCall) Do() (*ActivityPage, error) {
+	return c.DoContext(c.ctx)
+}
+
+// DoContext executes the call bound to ctx, overriding any context set with Context.
+func (c *ActivityListCall) DoContext(ctx context.Context) (*ActivityPage, error) {
+	path := withQuery(c.s.versioned("me/activities"), c.params)
+	env := &Envelope[ActivityPage]{}
+	resp, err := c.s.get(withOperation(ctx, "me.activity.list", "me/activities"), path, env)
+	return unwrap(resp, env, err)
+}
+
+// Pages invokes f for each page of results, starting at the page token set on
+// the call, until the last page is reached or f returns an error.
+// The call's page token is restored when Pages returns.
+func (c *ActivityListCall) Pages(ctx context.Context, f func(*ActivityPage) error) error {
+	defer restoreParam(c.params, "page_token")()
+	for {
+		x, err := c.DoContext(ctx)
+		if err != nil {
+			return err
+		}
+		if err := f(x); err != nil {
+			return err
+		}
+		if x.NextPageToken == "" {
+			return nil
+		}
+		c.PageToken(x.NextPageToken)
+	}
+}
@@ -0,0 +1,122 @@
+package account_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+)
+
+func TestActivityListCall_Do(t *testing.T) {
+	since := time.Date(2018, 3, 1, 8, 0, 0, 0, time.FixedZone("CST", 8*60*60))
+	until := time.Date(2018, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name      string
+		givenCall func(s *account.Service) *account.ActivityListCall
+		wantQuery string
+	}{
+		{
+			name:      "without filters",
+			givenCall: func(s *account.Service) *account.ActivityListCall { return s.Me.Activity.List() },
+			wantQuery: "",
+		},
+		{
+			name: "with filters",
+			givenCall: func(s *account.Service) *account.ActivityListCall {
+				return s.Me.Activity.List().
+					Since(since).
+					Until(until).
+					EventType(account.ActivitySignIn, account.ActivitySignInFailed).
+					PageSize(20).
+					PageToken("abc")
+			},
+			wantQuery: "event_type=sign_in%2Csign_in_failed&page_size=20&page_token=abc&since=2018-03-01T00%3A00%3A00Z&until=2018-03-02T00%3A00%3A00Z",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotPath, gotQuery string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+				w.Write([]byte(`{"message":"OK","code":0,"result":{"activities":[{"id":"1","event_type":"sign_in","ip":"10.0.0.1"}]}}`))
+			}))
+			defer ts.Close()
+
+			s := account.New(ts.Client(), account.WithBaseURL(ts.URL))
+			res, err := tc.givenCall(s).Do()
+			assert.NoError(t, err)
+			assert.Equal(t, "/v1.1/me/activities", gotPath)
+			assert.Equal(t, tc.wantQuery, gotQuery)
+			assert.Equal(t, []account.Activity{{Id: "1", EventType: account.ActivitySignIn, Ip: "10.0.0.1"}}, res.Activities)
+		})
+	}
+}
+
+func TestActivityListCall_Pages(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch token := r.URL.Query().Get("page_token"); token {
+		case "":
+			w.Write([]byte(`{"message":"OK","code":0,"result":{"activities":[{"id":"1"},{"id":"2"}],"next_page_token":"p2"}}`))
+		case "p2":
+			w.Write([]byte(`{"message":"OK","code":0,"result":{"activities":[{"id":"3"}],"next_page_token":"p3"}}`))
+		case "p3":
+			w.Write([]byte(`{"message":"OK","code":0,"result":{"activities":[{"id":"4"}]}}`))
+		default:
+			t.Fatalf("unexpected page token %q", token)
+		}
+	}))
+	defer ts.Close()
+
+	s := account.New(ts.Client(), account.WithBaseURL(ts.URL))
+
+	tt := []struct {
+		name      string
+		givenStop string
+		wantIds   []string
+		wantError error
+	}{
+		{
+			name:    "all pages",
+			wantIds: []string{"1", "2", "3", "4"},
+		},
+		{
+			name:      "stop when callback fails",
+			givenStop: "3",
+			wantIds:   []string{"1", "2", "3"},
+			wantError: errors.New("stop at 3"),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			call := s.Me.Activity.List().PageSize(2)
+
+			var ids []string
+			err := call.Pages(context.Background(), func(page *account.ActivityPage) error {
+				for _, a := range page.Activities {
+					ids = append(ids, a.Id)
+					if a.Id == tc.givenStop {
+						return fmt.Errorf("stop at %s", a.Id)
+					}
+				}
+				return nil
+			})
+			assert.Equal(t, tc.wantError, err)
+			assert.Equal(t, tc.wantIds, ids)
+
+			// the page token is restored, so the call starts over from the first page
+			res, err := call.Do()
+			assert.NoError(t, err)
+			assert.Equal(t, "p2", res.NextPageToken)
+		})
+	}
+}
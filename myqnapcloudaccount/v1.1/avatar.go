@@ -0,0 +1,174 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+)
+
+// AvatarSize selects one of the avatar renditions kept for a user.
+type AvatarSize string
+
+const (
+	AvatarSmall  AvatarSize = "small"
+	AvatarMedium AvatarSize = "medium"
+	AvatarIcon   AvatarSize = "icon"
+)
+
+type Avatars struct {
+	Small  string     `json:"small"`
+	Medium string     `json:"medium"`
+	Cn     AvatarUrls `json:"cn"` // renditions served from the China region
+	Icon   string     `json:"icon"`
+}
+
+type AvatarUrls struct {
+	Small  string `json:"small"`
+	Medium string `json:"medium"`
+	Icon   string `json:"icon"`
+}
+
+type AvatarService struct {
+	s *Service
+}
+
+func NewAvatarService(s *Service) *AvatarService {
+	rs := &AvatarService{s: s}
+	return rs
+}
+
+// Avatar upload call
+type AvatarUploadCall struct {
+	s   *Service
+	ctx context.Context
+
+	r           io.Reader
+	contentType string
+}
+
+// Upload replaces the avatar of the current user with the image read from r.
+// contentType is the MIME type of the image, e.g. "image/png".
+func (r *AvatarService) Upload(image io.Reader, contentType string) *AvatarUploadCall {
+	c := &AvatarUploadCall{s: r.s, r: image, contentType: contentType}
+	return c
+}
+
+// Context sets the context to be used in this call's Do method.
+// Any pending HTTP request will be aborted if the provided context is canceled.
+func (c *AvatarUploadCall) Context(ctx context.Context) *AvatarUploadCall {
+	c.ctx = ctx
+	return c
+}
+
+func (c *AvatarUploadCall) Do() (*Avatars, error) {
+	return c.DoContext(c.ctx)
+}
+
+// DoContext executes the call bound to ctx, overriding any context set with Context.
+func (c *AvatarUploadCall) DoContext(ctx context.Context) (*Avatars, error) {
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="avatar"; filename="avatar"`)
+	h.Set("Content-Type", c.contentType)
+	part, err := mw.CreatePart(h)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.Copy(part, c.r); err != nil {
+		return nil, err
+	}
+	if err = mw.Close(); err != nil {
+		return nil, err
+	}
+
+	path := c.s.versioned("me/avatar")
+	req, err := c.s.newRequest(withOperation(ctx, "me.avatar.upload", "me/avatar"), "PUT", path, body, mw.FormDataContentType())
+	if err != nil {
+		return nil, err
+	}
+
+	env := &Envelope[Avatars]{}
+	resp, err := c.s.do(req, env)
+	return unwrap(resp, env, err)
+}
+
+// Avatar download call
+type AvatarDownloadCall struct {
+	s   *Service
+	ctx context.Context
+
+	size AvatarSize
+	w    io.Writer
+}
+
+// Download streams the avatar image of the current user in the given size to w.
+func (r *AvatarService) Download(size AvatarSize, w io.Writer) *AvatarDownloadCall {
+	c := &AvatarDownloadCall{s: r.s, size: size, w: w}
+	return c
+}
+
+// Context sets the context to be used in this call's Do method.
+// Any pending HTTP request will be aborted if the provided context is canceled.
+func (c *AvatarDownloadCall) Context(ctx context.Context) *AvatarDownloadCall {
+	c.ctx = ctx
+	return c
+}
+
+func (c *AvatarDownloadCall) Do() error {
+	return c.DoContext(c.ctx)
+}
+
+// DoContext executes the call bound to ctx, overriding any context set with Context.
+func (c *AvatarDownloadCall) DoContext(ctx context.Context) error {
+	if c.w == nil {
+		return errors.New("avatar download: nil writer")
+	}
+
+	path := withQuery(c.s.versioned("me/avatar"), url.Values{"size": {string(c.size)}})
+	req, err := c.s.newRequest(withOperation(ctx, "me.avatar.download", "me/avatar"), "GET", path, nil, "application/json")
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "image/*")
+
+	_, err = c.s.do(req, c.w)
+	return err
+}
+
+// Avatar delete call
+type AvatarDeleteCall struct {
+	s   *Service
+	ctx context.Context
+}
+
+// Delete removes the avatar of the current user, restoring the default one.
+func (r *AvatarService) Delete() *AvatarDeleteCall {
+	c := &AvatarDeleteCall{s: r.s}
+	return c
+}
+
+// Context sets the context to be used in this call's Do method.
+// Any pending HTTP request will be aborted if the provided context is canceled.
+func (c *AvatarDeleteCall) Context(ctx context.Context) *AvatarDeleteCall {
+	c.ctx = ctx
+	return c
+}
+
+func (c *AvatarDeleteCall) Do() error {
+	return c.DoContext(c.ctx)
+}
+
+// DoContext executes the call bound to ctx, overriding any context set with Context.
+func (c *AvatarDeleteCall) DoContext(ctx context.Context) error {
+	path := c.s.versioned("me/avatar")
+	env := &Envelope[struct{}]{}
+	resp, err := c.s.delete(withOperation(ctx, "me.avatar.delete", "me/avatar"), path, nil, env)
+	_, err = unwrap(resp, env, err)
+	return err
+}
@@ -0,0 +1,98 @@
+package account_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+)
+
+func TestAvatarUploadCall_Do(t *testing.T) {
+	var gotMethod, gotPath, gotContentType string
+	var gotImage []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		f, h, err := r.FormFile("avatar")
+		if err != nil {
+			t.Fatalf("%v, unexpected multipart body", err)
+		}
+		defer f.Close()
+		gotContentType = h.Header.Get("Content-Type")
+		gotImage, _ = io.ReadAll(f)
+		w.Write([]byte(`{"message":"OK","code":0,"result":{"small":"https://a/s.jpg","medium":"https://a/m.jpg","icon":"https://a/i.jpg","cn":{"small":"https://cn/s.jpg"}}}`))
+	}))
+	defer ts.Close()
+
+	s := account.New(ts.Client(), account.WithBaseURL(ts.URL))
+	res, err := s.Me.Avatar.Upload(strings.NewReader("\x89PNG fake image"), "image/png").Do()
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPut, gotMethod)
+	assert.Equal(t, "/v1.1/me/avatar", gotPath)
+	assert.Equal(t, "image/png", gotContentType)
+	assert.Equal(t, []byte("\x89PNG fake image"), gotImage)
+	assert.Equal(t, "https://a/m.jpg", res.Medium)
+	assert.Equal(t, "https://cn/s.jpg", res.Cn.Small)
+}
+
+func TestAvatarDownloadCall_Do(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1.1/me/avatar" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Query().Get("size") {
+		case "small":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("small-jpeg"))
+		case "icon":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("icon-jpeg"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"avatar not found","code":40400}`))
+		}
+	}))
+	defer ts.Close()
+
+	s := account.New(ts.Client(), account.WithBaseURL(ts.URL))
+
+	tt := []struct {
+		name      string
+		givenSize account.AvatarSize
+		wantBody  string
+		wantError bool
+	}{
+		{name: "small", givenSize: account.AvatarSmall, wantBody: "small-jpeg"},
+		{name: "icon", givenSize: account.AvatarIcon, wantBody: "icon-jpeg"},
+		{name: "fail with not found", givenSize: account.AvatarMedium, wantError: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			err := s.Me.Avatar.Download(tc.givenSize, buf).Do()
+			if tc.wantError {
+				assert.IsType(t, &account.ErrorResponse{}, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantBody, buf.String())
+		})
+	}
+}
+
+func TestAvatarDeleteCall_Do(t *testing.T) {
+	ts, got := newRecordingServer(t, http.StatusOK, `{"message":"OK","code":0}`)
+	defer ts.Close()
+
+	s := account.New(ts.Client(), account.WithBaseURL(ts.URL))
+	err := s.Me.Avatar.Delete().Do()
+	assert.NoError(t, err)
+	assert.Equal(t, recordedRequest{Method: http.MethodDelete, Path: "/v1.1/me/avatar"}, *got)
+}
@@ -0,0 +1,7 @@
+// +build !alpha,!dev
+
+package account_test
+
+import account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+
+var wantDefaultEnvironment = account.Production
@@ -3,6 +3,5 @@
 package account
 
 func init() {
-	apiVersion = "v1.1"
-	basePath = "https://core.api.dev.myqnapcloud.com"
+	defaultEnvironment = Dev
 }
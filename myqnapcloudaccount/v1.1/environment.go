@@ -0,0 +1,62 @@
+package account
+
+// Environment describes a myQNAPcloud deployment the client talks to.
+type Environment struct {
+	BasePath   string // API endpoint base URL
+	APIVersion string
+
+	// AuthBasePath is the base URL of the OAuth2 authorization server, BasePath
+	// when empty.
+	AuthBasePath string
+}
+
+// The authorization servers of the environments are not documented by
+// myQNAPcloud; they are assumed to be served by the API hosts.
+var (
+	Dev = Environment{
+		BasePath:     "https://core.api.dev.myqnapcloud.com",
+		APIVersion:   "v1.1",
+		AuthBasePath: "https://core.api.dev.myqnapcloud.com",
+	}
+	Alpha = Environment{
+		BasePath:     "https://core.api.alpha-myqnapcloud.com",
+		APIVersion:   "v1.1",
+		AuthBasePath: "https://core.api.alpha-myqnapcloud.com",
+	}
+	Production = Environment{
+		BasePath:     "https://core.api.myqnapcloud.com",
+		APIVersion:   "v1.1",
+		AuthBasePath: "https://core.api.myqnapcloud.com",
+	}
+)
+
+// DefaultEnvironment returns the environment selected at build time, which
+// Services use unless told otherwise.
+func DefaultEnvironment() Environment {
+	return defaultEnvironment
+}
+
+// Option configures a Service created by New.
+type Option func(*Service)
+
+// WithEnvironment points the Service at env, overriding the build default.
+func WithEnvironment(env Environment) Option {
+	return func(s *Service) {
+		s.BasePath = env.BasePath
+		s.APIVersion = env.APIVersion
+	}
+}
+
+// WithBaseURL overrides the API endpoint base URL.
+func WithBaseURL(url string) Option {
+	return func(s *Service) {
+		s.BasePath = url
+	}
+}
+
+// WithAPIVersion overrides the API version prefixed to every path.
+func WithAPIVersion(version string) Option {
+	return func(s *Service) {
+		s.APIVersion = version
+	}
+}
@@ -0,0 +1,65 @@
+package account
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrorCode is the code reported in the body of a failed myQNAPcloud API response.
+type ErrorCode int
+
+const CodeOK ErrorCode = 0
+
+// Codes reported for rejected password changes and resets. They are not
+// documented by myQNAPcloud; the values are assumed and may be reassigned
+// to the ones of the API in use.
+var (
+	ErrorCodeWeakPassword      ErrorCode = 40010
+	ErrorCodeWrongPassword     ErrorCode = 40011
+	ErrorCodeInvalidResetToken ErrorCode = 40012
+)
+
+// Sentinel errors matched by ErrorResponse according to its HTTP status.
+var (
+	ErrBadRequest   = errors.New("myqnapcloud: bad request")
+	ErrUnauthorized = errors.New("myqnapcloud: unauthorized")
+	ErrForbidden    = errors.New("myqnapcloud: forbidden")
+	ErrNotFound     = errors.New("myqnapcloud: not found")
+	ErrConflict     = errors.New("myqnapcloud: conflict")
+	ErrRateLimited  = errors.New("myqnapcloud: rate limited")
+	ErrServer       = errors.New("myqnapcloud: server error")
+)
+
+func statusError(code int) error {
+	switch {
+	case code == http.StatusBadRequest:
+		return ErrBadRequest
+	case code == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case code == http.StatusForbidden:
+		return ErrForbidden
+	case code == http.StatusNotFound:
+		return ErrNotFound
+	case code == http.StatusConflict:
+		return ErrConflict
+	case code == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case code >= 500:
+		return ErrServer
+	}
+	return nil
+}
+
+const (
+	maxErrorBodySize = 64 << 10
+	maxSnippetSize   = 512
+)
+
+// snippet returns the leading part of body as a single line of valid UTF-8.
+func snippet(body []byte) string {
+	if len(body) > maxSnippetSize {
+		body = body[:maxSnippetSize]
+	}
+	return strings.Join(strings.Fields(strings.ToValidUTF8(string(body), "")), " ")
+}
@@ -0,0 +1,164 @@
+package account_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+)
+
+func TestCheckResponse_ErrorResponse(t *testing.T) {
+	tt := []struct {
+		name          string
+		givenStatus   int
+		givenBody     string
+		wantSentinel  error
+		wantMessage   string
+		wantCode      account.ErrorCode
+		wantBody      string
+		wantRateLimit bool
+	}{
+		{
+			name:         "json error body",
+			givenStatus:  http.StatusUnauthorized,
+			givenBody:    `{"message":"invalid token","code":40100}`,
+			wantSentinel: account.ErrUnauthorized,
+			wantMessage:  "invalid token",
+			wantCode:     40100,
+			wantBody:     `{"message":"invalid token","code":40100}`,
+		},
+		{
+			name:         "html body from a proxy",
+			givenStatus:  http.StatusBadGateway,
+			givenBody:    "<html>\n  <body>502 Bad Gateway</body>\n</html>",
+			wantSentinel: account.ErrServer,
+			wantBody:     "<html> <body>502 Bad Gateway</body> </html>",
+		},
+		{
+			name:         "empty body",
+			givenStatus:  http.StatusNotFound,
+			wantSentinel: account.ErrNotFound,
+		},
+		{
+			name:         "long body is cut",
+			givenStatus:  http.StatusForbidden,
+			givenBody:    strings.Repeat("x", 2000),
+			wantSentinel: account.ErrForbidden,
+			wantBody:     strings.Repeat("x", 512),
+		},
+		{
+			name:          "rate limited",
+			givenStatus:   http.StatusTooManyRequests,
+			givenBody:     `{"message":"slow down","code":42900}`,
+			wantSentinel:  account.ErrRateLimited,
+			wantMessage:   "slow down",
+			wantCode:      42900,
+			wantBody:      `{"message":"slow down","code":42900}`,
+			wantRateLimit: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Request-Id", "req-123")
+				w.WriteHeader(tc.givenStatus)
+				w.Write([]byte(tc.givenBody))
+			}))
+			defer ts.Close()
+
+			s := account.New(ts.Client(), account.WithBaseURL(ts.URL))
+			_, err := s.Me.Get().Do()
+
+			assert.True(t, errors.Is(err, tc.wantSentinel), "%v was expected to match %v", err, tc.wantSentinel)
+			for _, other := range []error{account.ErrBadRequest, account.ErrUnauthorized, account.ErrForbidden, account.ErrNotFound, account.ErrConflict, account.ErrRateLimited, account.ErrServer} {
+				if other != tc.wantSentinel {
+					assert.False(t, errors.Is(err, other), "%v was not expected to match %v", err, other)
+				}
+			}
+
+			var rateLimitErr *account.RateLimitError
+			assert.Equal(t, tc.wantRateLimit, errors.As(err, &rateLimitErr))
+
+			var e *account.ErrorResponse
+			if assert.True(t, errors.As(err, &e)) {
+				assert.Equal(t, tc.givenStatus, e.StatusCode)
+				assert.Equal(t, http.MethodGet, e.Method)
+				assert.Equal(t, ts.URL+"/v1.1/me", e.URL)
+				assert.Equal(t, "req-123", e.RequestId)
+				assert.Equal(t, tc.wantMessage, e.Message)
+				assert.Equal(t, tc.wantCode, e.Code)
+				assert.Equal(t, tc.wantBody, e.Body)
+				assert.Contains(t, e.Error(), "req-123")
+			}
+		})
+	}
+}
+
+func TestEnvelope_Code(t *testing.T) {
+	tt := []struct {
+		name        string
+		givenStatus int
+		givenBody   string
+		givenCall   func(s *account.Service) error
+		wantCode    account.ErrorCode
+		wantMessage string
+	}{
+		{
+			name:        "success",
+			givenStatus: http.StatusOK,
+			givenBody:   `{"message":"OK","code":0,"result":{"user_id":"u1"}}`,
+			givenCall:   func(s *account.Service) error { _, err := s.Me.Get().Do(); return err },
+		},
+		{
+			name:        "error code with 200 status",
+			givenStatus: http.StatusOK,
+			givenBody:   `{"message":"password too weak","code":40010,"result":null}`,
+			givenCall:   func(s *account.Service) error { return s.Me.Password.Change().Old("a").New("b").Do() },
+			wantCode:    account.ErrorCodeWeakPassword,
+			wantMessage: "password too weak",
+		},
+		{
+			name:        "error code with result",
+			givenStatus: http.StatusOK,
+			givenBody:   `{"message":"user suspended","code":40300,"result":{"user_id":"u1"}}`,
+			givenCall:   func(s *account.Service) error { _, err := s.User.Get("u1").Do(); return err },
+			wantCode:    40300,
+			wantMessage: "user suspended",
+		},
+		{
+			name:        "empty body",
+			givenStatus: http.StatusNoContent,
+			givenCall:   func(s *account.Service) error { return s.Friend.Remove("u1").Do() },
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.givenStatus)
+				w.Write([]byte(tc.givenBody))
+			}))
+			defer ts.Close()
+
+			s := account.New(ts.Client(), account.WithBaseURL(ts.URL))
+			err := tc.givenCall(s)
+			if tc.wantCode == account.CodeOK {
+				assert.NoError(t, err)
+				return
+			}
+
+			var e *account.ErrorResponse
+			if assert.True(t, errors.As(err, &e)) {
+				assert.Equal(t, tc.wantCode, e.Code)
+				assert.Equal(t, tc.wantMessage, e.Message)
+				assert.Equal(t, tc.givenStatus, e.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package account
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// Friend status values.
+const (
+	FriendStatusPending  = "pending"  // invitation sent, waiting for the invitee
+	FriendStatusInvited  = "invited"  // invitation received, waiting for Accept
+	FriendStatusAccepted = "accepted" // both sides are friends
+)
+
+type Friend struct {
+	UserId      string  `json:"user_id"`
+	Email       string  `json:"email"`
+	DisplayName string  `json:"display_name"`
+	Status      string  `json:"status"`
+	Avatars     Avatars `json:"avatars"`
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   string  `json:"updated_at"`
+}
+
+type FriendPage struct {
+	Friends       []Friend `json:"friends"`
+	NextPageToken string   `json:"next_page_token"`
+}
+
+type FriendService struct {
+	s *Service
+}
+
+func NewFriendService(s *Service) *FriendService {
+	rs := &FriendService{s: s}
+	return rs
+}
+
+func friendPath(s *Service, userId string, action ...string) string {
+	path := "friends/" + url.PathEscape(userId)
+	for _, a := range action {
+		path += "/" + a
+	}
+	return s.versioned(path)
+}
+
+// Friend list call
+type FriendListCall struct {
+	s      *Service
+	ctx    context.Context
+	params url.Values
+}
+
+// List returns the friends of the current user, including pending invitations.
+func (r *FriendService) List() *FriendListCall {
+	c := &FriendListCall{s: r.s, params: url.Values{}}
+	return c
+}
+
+// Status limits the results to friends in the given status, e.g. FriendStatusAccepted.
+func (c *FriendListCall) Status(status string) *FriendListCall {
+	c.params.Set("status", status)
+	return c
+}
+
+// PageSize sets the maximum number of friends returned per page.
+func (c *FriendListCall) PageSize(pageSize int) *FriendListCall {
+	c.params.Set("page_size", strconv.Itoa(pageSize))
+	return c
+}
+
+// PageToken sets the page to retrieve, as returned in FriendPage.NextPageToken.
+func (c *FriendListCall) PageToken(pageToken string) *FriendListCall {
+	c.params.Set("page_token", pageToken)
+	return c
+}
+
+// Context sets the context to be used in this call's Do method.
+// Any pending HTTP request will be aborted if the provided context is canceled.
+func (c *FriendListCall) Context(ctx context.Context) *FriendListCall {
+	c.ctx = ctx
+	return c
+}
+
+func (c *FriendListCall) Do() (*FriendPage, error) {
+	return c.DoContext(c.ctx)
+}
+
+// DoContext executes the call bound to ctx, overriding any context set with Context.
+func (c *FriendListCall) DoContext(ctx context.Context) (*FriendPage, error) {
+	path := withQuery(c.s.versioned("friends"), c.params)
+	env := &Envelope[FriendPage]{}
+	resp, err := c.s.get(withOperation(ctx, "friend.list", "friends"), path, env)
+	return unwrap(resp, env, err)
+}
+
+// Pages invokes f for each page of results, starting at the page token set on
+// the call, until the last page is reached or f returns an error.
+// The call's page token is restored when Pages returns.
+func (c *FriendListCall) Pages(ctx context.Context, f func(*FriendPage) error) error {
+	defer restoreParam(c.params, "page_token")()
+	for {
+		x, err := c.DoContext(ctx)
+		if err != nil {
+			return err
+		}
+		if err := f(x); err != nil {
+			return err
+		}
+		if x.NextPageToken == "" {
+			return nil
+		}
+		c.PageToken(x.NextPageToken)
+	}
+}
+
+// Friend invite call
+type FriendInviteCall struct {
+	s   *Service
+	ctx context.Context
+
+	email string
+}
+
+// Invite sends a friend invitation to the myQNAPcloud account registered with email.
+func (r *FriendService) Invite(email string) *FriendInviteCall {
+	c := &FriendInviteCall{s: r.s, email: email}
+	return c
+}
+
+// Context sets the context to be used in this call's Do method.
+// Any pending HTTP request will be aborted if the provided context is canceled.
+func (c *FriendInviteCall) Context(ctx context.Context) *FriendInviteCall {
+	c.ctx = ctx
+	return c
+}
+
+func (c *FriendInviteCall) Do() (*Friend, error) {
+	return c.DoContext(c.ctx)
+}
+
+// DoContext executes the call bound to ctx, overriding any context set with Context.
+func (c *FriendInviteCall) DoContext(ctx context.Context) (*Friend, error) {
+	path := c.s.versioned("friends")
+	payload := map[string]string{
+		"email": c.email,
+	}
+	env := &Envelope[Friend]{}
+	resp, err := c.s.post(withOperation(ctx, "friend.invite", "friends"), path, payload, env)
+	return unwrap(resp, env, err)
+}
+
+// Friend accept call
+type FriendAcceptCall struct {
+	s   *Service
+	ctx context.Context
+
+	userId string
+}
+
+// Accept accepts the friend invitation received from userId.
+func (r *FriendService) Accept(userId string) *FriendAcceptCall {
+	c := &FriendAcceptCall{s: r.s, userId: userId}
+	return c
+}
+
+// Context sets the context to be used in this call's Do method.
+// Any pending HTTP request will be aborted if the provided context is canceled.
+func (c *FriendAcceptCall) Context(ctx context.Context) *FriendAcceptCall {
+	c.ctx = ctx
+	return c
+}
+
+func (c *FriendAcceptCall) Do() (*Friend, error) {
+	return c.DoContext(c.ctx)
+}
+
+// DoContext executes the call bound to ctx, overriding any context set with Context.
+func (c *FriendAcceptCall) DoContext(ctx context.Context) (*Friend, error) {
+	path := friendPath(c.s, c.userId, "accept")
+	env := &Envelope[Friend]{}
+	resp, err := c.s.post(withOperation(ctx, "friend.accept", "friends/{user_id}/accept"), path, nil, env)
+	return unwrap(resp, env, err)
+}
+
+// Friend remove call
+type FriendRemoveCall struct {
+	s   *Service
+	ctx context.Context
+
+	userId string
+}
+
+// Remove removes userId from the friends of the current user, or withdraws or
+// declines a pending invitation.
+func (r *FriendService) Remove(userId string) *FriendRemoveCall {
+	c := &FriendRemoveCall{s: r.s, userId: userId}
+	return c
+}
+
+// Context sets the context to be used in this call's Do method.
+// Any pending HTTP request will be aborted if the provided context is canceled.
+func (c *FriendRemoveCall) Context(ctx context.Context) *FriendRemoveCall {
+	c.ctx = ctx
+	return c
+}
+
+func (c *FriendRemoveCall) Do() error {
+	return c.DoContext(c.ctx)
+}
+
+// DoContext executes the call bound to ctx, overriding any context set with Context.
+func (c *FriendRemoveCall) DoContext(ctx context.Context) error {
+	path := friendPath(c.s, c.userId)
+	env := &Envelope[struct{}]{}
+	resp, err := c.s.delete(withOperation(ctx, "friend.remove", "friends/{user_id}"), path, nil, env)
+	_, err = unwrap(resp, env, err)
+	return err
+}
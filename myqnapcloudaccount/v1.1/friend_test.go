@@ -0,0 +1,131 @@
+package account_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+)
+
+func TestFriendListCall_Pages(t *testing.T) {
+	var gotStatus []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1.1/friends" {
+			http.NotFound(w, r)
+			return
+		}
+		gotStatus = append(gotStatus, r.URL.Query().Get("status"))
+		switch r.URL.Query().Get("page_token") {
+		case "":
+			w.Write([]byte(`{"message":"OK","code":0,"result":{"friends":[{"user_id":"u1","email":"a@example.com","status":"accepted"}],"next_page_token":"p2"}}`))
+		case "p2":
+			w.Write([]byte(`{"message":"OK","code":0,"result":{"friends":[{"user_id":"u2","email":"b@example.com","status":"accepted","avatars":{"icon":"https://a/i.jpg"}}]}}`))
+		}
+	}))
+	defer ts.Close()
+
+	s := account.New(ts.Client(), account.WithBaseURL(ts.URL))
+
+	var friends []account.Friend
+	err := s.Friend.List().Status(account.FriendStatusAccepted).PageSize(1).Pages(context.Background(), func(page *account.FriendPage) error {
+		friends = append(friends, page.Friends...)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"accepted", "accepted"}, gotStatus)
+	if assert.Len(t, friends, 2) {
+		assert.Equal(t, "u1", friends[0].UserId)
+		assert.Equal(t, "u2", friends[1].UserId)
+		assert.Equal(t, "https://a/i.jpg", friends[1].Avatars.Icon)
+	}
+}
+
+func TestFriendService_Calls(t *testing.T) {
+	tt := []struct {
+		name        string
+		givenStatus int
+		givenBody   string
+		givenCall   func(s *account.Service) error
+		wantRequest recordedRequest
+		wantError   bool
+	}{
+		{
+			name:        "invite",
+			givenStatus: http.StatusOK,
+			givenBody:   `{"message":"OK","code":0,"result":{"user_id":"u3","email":"c@example.com","status":"pending"}}`,
+			givenCall: func(s *account.Service) error {
+				res, err := s.Friend.Invite("c@example.com").Do()
+				if err == nil {
+					assert.Equal(t, account.FriendStatusPending, res.Status)
+				}
+				return err
+			},
+			wantRequest: recordedRequest{
+				Method: http.MethodPost,
+				Path:   "/v1.1/friends",
+				Body:   map[string]string{"email": "c@example.com"},
+			},
+		},
+		{
+			name:        "accept",
+			givenStatus: http.StatusOK,
+			givenBody:   `{"message":"OK","code":0,"result":{"user_id":"u/4","status":"accepted"}}`,
+			givenCall: func(s *account.Service) error {
+				res, err := s.Friend.Accept("u/4").Do()
+				if err == nil {
+					assert.Equal(t, account.FriendStatusAccepted, res.Status)
+				}
+				return err
+			},
+			wantRequest: recordedRequest{
+				Method: http.MethodPost,
+				Path:   "/v1.1/friends/u/4/accept",
+			},
+		},
+		{
+			name:        "remove",
+			givenStatus: http.StatusOK,
+			givenBody:   `{"message":"OK","code":0}`,
+			givenCall: func(s *account.Service) error {
+				return s.Friend.Remove("u5").Do()
+			},
+			wantRequest: recordedRequest{
+				Method: http.MethodDelete,
+				Path:   "/v1.1/friends/u5",
+			},
+		},
+		{
+			name:        "fail remove with unknown friend",
+			givenStatus: http.StatusNotFound,
+			givenBody:   `{"message":"friend not found","code":40400}`,
+			givenCall: func(s *account.Service) error {
+				return s.Friend.Remove("nobody").Do()
+			},
+			wantRequest: recordedRequest{
+				Method: http.MethodDelete,
+				Path:   "/v1.1/friends/nobody",
+			},
+			wantError: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ts, got := newRecordingServer(t, tc.givenStatus, tc.givenBody)
+			defer ts.Close()
+
+			s := account.New(ts.Client(), account.WithBaseURL(ts.URL))
+			err := tc.givenCall(s)
+			if tc.wantError {
+				assert.IsType(t, &account.ErrorResponse{}, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantRequest, *got)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package account
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/qeek-dev/qeek-api-go-client/internal/redact"
+)
+
+// WithLogger makes the Service log every request attempt and its response to
+// l at debug level. Authorization headers, tokens and passwords are redacted.
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Service) {
+		s.logger = l
+	}
+}
+
+// logEnabled reports whether request logs are written, either to the logger
+// set by WithLogger or, when Debug is set, to slog.Default at info level.
+func (c *Service) logEnabled(ctx context.Context) (*slog.Logger, slog.Level, bool) {
+	switch {
+	case c.logger != nil:
+		return c.logger, slog.LevelDebug, c.logger.Enabled(ctx, slog.LevelDebug)
+	case c.Debug:
+		return slog.Default(), slog.LevelInfo, true
+	}
+	return nil, 0, false
+}
+
+func (c *Service) logRequest(req *http.Request, attempt int) {
+	ctx := req.Context()
+	l, level, ok := c.logEnabled(ctx)
+	if !ok {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("operation", Operation(ctx)),
+		slog.String("method", req.Method),
+		slog.String("url", redact.URL(req.URL)),
+		slog.Int("attempt", attempt),
+		slog.Any("header", redact.Header(req.Header)),
+	}
+	if body := requestBody(req); body != "" {
+		attrs = append(attrs, slog.String("body", body))
+	}
+	l.LogAttrs(ctx, level, "myqnapcloud request", attrs...)
+}
+
+func (c *Service) logResponse(req *http.Request, resp *http.Response, err error, attempt int, elapsed time.Duration) {
+	ctx := req.Context()
+	l, level, ok := c.logEnabled(ctx)
+	if !ok {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("operation", Operation(ctx)),
+		slog.String("method", req.Method),
+		slog.String("url", redact.URL(req.URL)),
+		slog.Int("attempt", attempt),
+		slog.Duration("duration", elapsed),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	} else {
+		attrs = append(attrs,
+			slog.Int("status", resp.StatusCode),
+			slog.String("request_id", resp.Header.Get("X-Request-Id")),
+		)
+	}
+	l.LogAttrs(ctx, level, "myqnapcloud response", attrs...)
+}
+
+// requestBody returns the redacted JSON body of req, or "" when it has no
+// body or the body is not JSON, e.g. a multipart avatar upload.
+func requestBody(req *http.Request) string {
+	if req.GetBody == nil || !strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+		return ""
+	}
+	rc, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(io.LimitReader(rc, maxErrorBodySize))
+	if err != nil {
+		return ""
+	}
+	return redact.JSON(b)
+}
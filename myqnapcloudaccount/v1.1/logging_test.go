@@ -0,0 +1,53 @@
+package account_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+)
+
+func TestWithLogger(t *testing.T) {
+	ts, _ := newRecordingServer(t, http.StatusOK, `{"message":"OK","code":0,"result":{}}`)
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	auth := func(next account.Handler) account.Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("Authorization", "Bearer secret-token")
+			return next(req)
+		}
+	}
+	s := account.New(ts.Client(), account.WithBaseURL(ts.URL), account.WithLogger(logger), account.WithMiddleware(auth))
+
+	err := s.Me.Password.Change().Old("old-secret").New("new-secret").Do()
+	assert.NoError(t, err)
+
+	got := buf.String()
+	for _, secret := range []string{"secret-token", "old-secret", "new-secret"} {
+		assert.NotContains(t, got, secret)
+	}
+	assert.Contains(t, got, `"msg":"myqnapcloud request"`)
+	assert.Contains(t, got, `"msg":"myqnapcloud response"`)
+	assert.Contains(t, got, `"operation":"me.password.change"`)
+	assert.Contains(t, got, `"status":200`)
+	assert.Contains(t, got, `[REDACTED]`)
+}
+
+func TestWithLogger_LevelDisabled(t *testing.T) {
+	ts, _ := newRecordingServer(t, http.StatusOK, `{"message":"OK","code":0,"result":{}}`)
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	s := account.New(ts.Client(), account.WithBaseURL(ts.URL), account.WithLogger(logger))
+
+	_, err := s.Me.Get().Do()
+	assert.NoError(t, err)
+	assert.Empty(t, buf.String())
+}
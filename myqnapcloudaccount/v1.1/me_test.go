@@ -0,0 +1,82 @@
+package account_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+)
+
+func TestMeUpdateCall_Do(t *testing.T) {
+	tt := []struct {
+		name      string
+		givenCall func(c *account.MeUpdateCall) *account.MeUpdateCall
+		wantBody  map[string]interface{}
+		wantError bool
+	}{
+		{
+			name: "single field",
+			givenCall: func(c *account.MeUpdateCall) *account.MeUpdateCall {
+				return c.DisplayName("Gary")
+			},
+			wantBody: map[string]interface{}{"display_name": "Gary"},
+		},
+		{
+			name: "zero values are sent when set",
+			givenCall: func(c *account.MeUpdateCall) *account.MeUpdateCall {
+				return c.Subscribed(false).PortalNotify(false).Gender(0).MobileNumber("")
+			},
+			wantBody: map[string]interface{}{"subscribed": false, "portal_notify": false, "gender": float64(0), "mobile_number": ""},
+		},
+		{
+			name: "all fields",
+			givenCall: func(c *account.MeUpdateCall) *account.MeUpdateCall {
+				return c.DisplayName("Gary").FirstName("Gary").LastName("Chen").Language("TCH").Gender(1).
+					Brithday("1990-01-01").MobileNumber("+886900000000").Subscribed(true).PortalNotify(true)
+			},
+			wantBody: map[string]interface{}{
+				"display_name": "Gary", "first_name": "Gary", "last_name": "Chen", "language": "TCH", "gender": float64(1),
+				"brithday": "1990-01-01", "mobile_number": "+886900000000", "subscribed": true, "portal_notify": true,
+			},
+		},
+		{
+			name: "fail with no fields set",
+			givenCall: func(c *account.MeUpdateCall) *account.MeUpdateCall {
+				return c
+			},
+			wantError: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotMethod, gotPath string
+			var gotBody map[string]interface{}
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotMethod, gotPath = r.Method, r.URL.Path
+				json.NewDecoder(r.Body).Decode(&gotBody)
+				w.Write([]byte(`{"message":"OK","code":0,"result":{"user_id":"u1","display_name":"Gary","language":"TCH"}}`))
+			}))
+			defer ts.Close()
+
+			s := account.New(ts.Client(), account.WithBaseURL(ts.URL))
+			res, err := tc.givenCall(s.Me.Update()).Do()
+			if tc.wantError {
+				assert.Error(t, err)
+				assert.Empty(t, gotMethod, "no request was expected")
+				return
+			}
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, http.MethodPatch, gotMethod)
+				assert.Equal(t, "/v1.1/me", gotPath)
+				assert.Equal(t, tc.wantBody, gotBody)
+				assert.Equal(t, "Gary", res.DisplayName)
+			}
+		})
+	}
+}
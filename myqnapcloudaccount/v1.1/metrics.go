@@ -0,0 +1,44 @@
+package account
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/qeek-dev/qeek-api-go-client/metrics"
+)
+
+// WithMetrics makes the Service record the count, latency and concurrency of
+// its API calls on c, labelled with the operation name and the API error code,
+// or the HTTP status when there is none.
+func WithMetrics(c *metrics.Collector) Option {
+	return func(s *Service) {
+		s.metrics = c
+	}
+}
+
+// coded is implemented by envelopes, whose code is checked after do returns.
+type coded interface {
+	code() ErrorCode
+}
+
+func (e *Envelope[T]) code() ErrorCode {
+	return e.Code
+}
+
+// outcome returns the metrics outcome and code label of a call.
+func outcome(resp *http.Response, obj interface{}, err error) (metrics.Outcome, string) {
+	var e *ErrorResponse
+	switch {
+	case errors.As(err, &e) && e.Code != CodeOK:
+		return metrics.Failure, strconv.Itoa(int(e.Code))
+	case err != nil && resp != nil:
+		return metrics.Failure, strconv.Itoa(resp.StatusCode)
+	case err != nil:
+		return metrics.Failure, ""
+	}
+	if env, ok := obj.(coded); ok && env.code() != CodeOK {
+		return metrics.Failure, strconv.Itoa(int(env.code()))
+	}
+	return metrics.Success, strconv.Itoa(resp.StatusCode)
+}
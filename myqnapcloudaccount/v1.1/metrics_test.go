@@ -0,0 +1,62 @@
+package account_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qeek-dev/qeek-api-go-client/metrics"
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+)
+
+func TestWithMetrics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.1/friends/u1":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"friend not found","code":40400}`))
+		case "/v1.1/friends/u2":
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`<html>bad gateway</html>`))
+		case "/v1.1/me/password":
+			w.Write([]byte(`{"message":"wrong password","code":40011}`))
+		default:
+			w.Write([]byte(`{"message":"OK","code":0,"result":{"user_id":"u1"}}`))
+		}
+	}))
+	defer ts.Close()
+
+	reg := prometheus.NewRegistry()
+	c, err := metrics.NewCollector(reg)
+	if !assert.NoError(t, err) {
+		return
+	}
+	s := account.New(ts.Client(), account.WithBaseURL(ts.URL), account.WithMetrics(c))
+
+	s.Me.Get().Do()
+	s.Me.Get().Do()
+	s.Friend.Remove("u1").Do()
+	s.Friend.Remove("u2").Do()
+	s.Me.Password.Change().Old("old").New("new").Do()
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP qeek_client_requests_total Number of client calls by operation, outcome and error code.
+# TYPE qeek_client_requests_total counter
+qeek_client_requests_total{client="myqnapcloud",code="200",operation="me.get",outcome="success"} 2
+qeek_client_requests_total{client="myqnapcloud",code="40400",operation="friend.remove",outcome="error"} 1
+qeek_client_requests_total{client="myqnapcloud",code="502",operation="friend.remove",outcome="error"} 1
+qeek_client_requests_total{client="myqnapcloud",code="40011",operation="me.password.change",outcome="error"} 1
+`), "qeek_client_requests_total"))
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP qeek_client_requests_in_flight Number of client calls in progress by operation.
+# TYPE qeek_client_requests_in_flight gauge
+qeek_client_requests_in_flight{client="myqnapcloud",operation="friend.remove"} 0
+qeek_client_requests_in_flight{client="myqnapcloud",operation="me.get"} 0
+qeek_client_requests_in_flight{client="myqnapcloud",operation="me.password.change"} 0
+`), "qeek_client_requests_in_flight"))
+}
@@ -0,0 +1,26 @@
+package account
+
+import "net/http"
+
+// Handler sends an API request and returns the raw HTTP response.
+type Handler func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps a Handler, e.g. to log, authenticate, measure, trace or
+// mutate requests. Operation(req.Context()) returns the name of the API call,
+// such as "me.get". Middleware may inspect the response but must leave its
+// body unread, the Service decodes it once the chain returns.
+type Middleware func(next Handler) Handler
+
+// Use appends m to the middleware chain of the Service. Middleware added
+// first is the outermost one. Each call goes through the chain once; rate
+// limiting and retries happen inside it.
+func (c *Service) Use(m ...Middleware) {
+	c.middleware = append(c.middleware, m...)
+}
+
+// WithMiddleware appends m to the middleware chain of the Service, see Service.Use.
+func WithMiddleware(m ...Middleware) Option {
+	return func(s *Service) {
+		s.Use(m...)
+	}
+}
@@ -0,0 +1,86 @@
+package account_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+)
+
+func TestService_Use(t *testing.T) {
+	var gotAuthorization string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuthorization = r.Header.Get("Authorization")
+		if r.URL.Path == "/v1.1/friends/u1" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"friend not found","code":40400}`))
+			return
+		}
+		w.Write([]byte(`{"message":"OK","code":0,"result":{"user_id":"u1"}}`))
+	}))
+	defer ts.Close()
+
+	var calls []string
+	record := func(name string) account.Middleware {
+		return func(next account.Handler) account.Handler {
+			return func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name+" > "+account.Operation(req.Context()))
+				resp, err := next(req)
+				if err == nil {
+					calls = append(calls, name+" < "+resp.Status)
+				}
+				return resp, err
+			}
+		}
+	}
+	auth := func(next account.Handler) account.Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("Authorization", "Bearer injected")
+			return next(req)
+		}
+	}
+
+	s := account.New(ts.Client(), account.WithBaseURL(ts.URL), account.WithMiddleware(record("outer")))
+	s.Use(record("inner"), auth)
+
+	tt := []struct {
+		name      string
+		givenCall func() error
+		wantCalls []string
+	}{
+		{
+			name:      "me get",
+			givenCall: func() error { _, err := s.Me.Get().Do(); return err },
+			wantCalls: []string{"outer > me.get", "inner > me.get", "inner < 200 OK", "outer < 200 OK"},
+		},
+		{
+			name:      "friend remove failing",
+			givenCall: func() error { return s.Friend.Remove("u1").Do() },
+			wantCalls: []string{"outer > friend.remove", "inner > friend.remove", "inner < 404 Not Found", "outer < 404 Not Found"},
+		},
+		{
+			name:      "avatar upload",
+			givenCall: func() error { _, err := s.Me.Avatar.Upload(strings.NewReader("img"), "image/png").Do(); return err },
+			wantCalls: []string{"outer > me.avatar.upload", "inner > me.avatar.upload", "inner < 200 OK", "outer < 200 OK"},
+		},
+		{
+			name:      "avatar download",
+			givenCall: func() error { return s.Me.Avatar.Download(account.AvatarSmall, new(bytes.Buffer)).Do() },
+			wantCalls: []string{"outer > me.avatar.download", "inner > me.avatar.download", "inner < 200 OK", "outer < 200 OK"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			calls, gotAuthorization = nil, ""
+			tc.givenCall()
+			assert.Equal(t, tc.wantCalls, calls)
+			assert.Equal(t, "Bearer injected", gotAuthorization)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package account
+
+import "context"
+
+type PasswordService struct {
+	s *Service
+}
+
+func NewPasswordService(s *Service) *PasswordService {
+	rs := &PasswordService{s: s}
+	return rs
+}
+
+// Password change call
+type PasswordChangeCall struct {
+	s   *Service
+	ctx context.Context
+
+	oldPassword string
+	newPassword string
+}
+
+// Change changes the password of the current user.
+// A wrong old password fails with ErrorCodeWrongPassword and a password
+// rejected by the password policy with ErrorCodeWeakPassword.
+func (r *PasswordService) Change() *PasswordChangeCall {
+	c := &PasswordChangeCall{s: r.s}
+	return c
+}
+
+func (c *PasswordChangeCall) Old(password string) *PasswordChangeCall {
+	c.oldPassword = password
+	return c
+}
+
+func (c *PasswordChangeCall) New(password string) *PasswordChangeCall {
+	c.newPassword = password
+	return c
+}
+
+// Context sets the context to be used in this call's Do method.
+// Any pending HTTP request will be aborted if the provided context is canceled.
+func (c *PasswordChangeCall) Context(ctx context.Context) *PasswordChangeCall {
+	c.ctx = ctx
+	return c
+}
+
+func (c *PasswordChangeCall) Do() error {
+	return c.DoContext(c.ctx)
+}
+
+// DoContext executes the call bound to ctx, overriding any context set with Context.
+func (c *PasswordChangeCall) DoContext(ctx context.Context) error {
+	path := c.s.versioned("me/password")
+	payload := map[string]string{
+		"old_password": c.oldPassword,
+		"new_password": c.newPassword,
+	}
+	env := &Envelope[struct{}]{}
+	// a replay after an applied change fails with a stale old password
+	ctx = withoutRetry(withOperation(ctx, "me.password.change", "me/password"))
+	resp, err := c.s.put(ctx, path, payload, env)
+	_, err = unwrap(resp, env, err)
+	return err
+}
+
+// Password reset request call
+type PasswordRequestResetCall struct {
+	s   *Service
+	ctx context.Context
+
+	email string
+}
+
+// RequestReset sends a password reset email carrying a reset token.
+func (r *PasswordService) RequestReset() *PasswordRequestResetCall {
+	c := &PasswordRequestResetCall{s: r.s}
+	return c
+}
+
+func (c *PasswordRequestResetCall) Email(email string) *PasswordRequestResetCall {
+	c.email = email
+	return c
+}
+
+// Context sets the context to be used in this call's Do method.
+// Any pending HTTP request will be aborted if the provided context is canceled.
+func (c *PasswordRequestResetCall) Context(ctx context.Context) *PasswordRequestResetCall {
+	c.ctx = ctx
+	return c
+}
+
+func (c *PasswordRequestResetCall) Do() error {
+	return c.DoContext(c.ctx)
+}
+
+// DoContext executes the call bound to ctx, overriding any context set with Context.
+func (c *PasswordRequestResetCall) DoContext(ctx context.Context) error {
+	path := c.s.versioned("password/reset")
+	payload := map[string]string{
+		"email": c.email,
+	}
+	env := &Envelope[struct{}]{}
+	resp, err := c.s.post(withOperation(ctx, "me.password.request_reset", "password/reset"), path, payload, env)
+	_, err = unwrap(resp, env, err)
+	return err
+}
+
+// Password reset confirm call
+type PasswordConfirmResetCall struct {
+	s   *Service
+	ctx context.Context
+
+	token       string
+	newPassword string
+}
+
+// ConfirmReset sets a new password using the token from the reset email.
+// An expired or unknown token fails with ErrorCodeInvalidResetToken and a
+// password rejected by the password policy with ErrorCodeWeakPassword.
+func (r *PasswordService) ConfirmReset() *PasswordConfirmResetCall {
+	c := &PasswordConfirmResetCall{s: r.s}
+	return c
+}
+
+func (c *PasswordConfirmResetCall) Token(token string) *PasswordConfirmResetCall {
+	c.token = token
+	return c
+}
+
+func (c *PasswordConfirmResetCall) New(password string) *PasswordConfirmResetCall {
+	c.newPassword = password
+	return c
+}
+
+// Context sets the context to be used in this call's Do method.
+// Any pending HTTP request will be aborted if the provided context is canceled.
+func (c *PasswordConfirmResetCall) Context(ctx context.Context) *PasswordConfirmResetCall {
+	c.ctx = ctx
+	return c
+}
+
+func (c *PasswordConfirmResetCall) Do() error {
+	return c.DoContext(c.ctx)
+}
+
+// DoContext executes the call bound to ctx, overriding any context set with Context.
+func (c *PasswordConfirmResetCall) DoContext(ctx context.Context) error {
+	path := c.s.versioned("password/reset/confirm")
+	payload := map[string]string{
+		"token":        c.token,
+		"new_password": c.newPassword,
+	}
+	env := &Envelope[struct{}]{}
+	resp, err := c.s.post(withOperation(ctx, "me.password.confirm_reset", "password/reset/confirm"), path, payload, env)
+	_, err = unwrap(resp, env, err)
+	return err
+}
@@ -0,0 +1,159 @@
+package account_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+)
+
+type recordedRequest struct {
+	Method string
+	Path   string
+	Body   map[string]string
+}
+
+// newRecordingServer replies to every request with status and body and
+// records the last request it received.
+func newRecordingServer(t *testing.T, status int, body string) (*httptest.Server, *recordedRequest) {
+	got := &recordedRequest{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.Method, got.Path, got.Body = r.Method, r.URL.Path, nil
+		if r.ContentLength > 0 {
+			if err := json.NewDecoder(r.Body).Decode(&got.Body); err != nil {
+				t.Fatalf("%v, unexpected request body", err)
+			}
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	return ts, got
+}
+
+func TestPasswordChangeCall_Do(t *testing.T) {
+	tt := []struct {
+		name        string
+		givenStatus int
+		givenBody   string
+		wantErrCode account.ErrorCode
+	}{
+		{
+			name:        "success",
+			givenStatus: http.StatusOK,
+			givenBody:   `{"message":"OK","code":0}`,
+		},
+		{
+			name:        "fail with wrong old password",
+			givenStatus: http.StatusForbidden,
+			givenBody:   `{"message":"wrong password","code":40011}`,
+			wantErrCode: account.ErrorCodeWrongPassword,
+		},
+		{
+			name:        "fail with weak password",
+			givenStatus: http.StatusBadRequest,
+			givenBody:   `{"message":"password too weak","code":40010}`,
+			wantErrCode: account.ErrorCodeWeakPassword,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ts, got := newRecordingServer(t, tc.givenStatus, tc.givenBody)
+			defer ts.Close()
+
+			s := account.New(ts.Client(), account.WithBaseURL(ts.URL))
+			err := s.Me.Password.Change().Old("old-secret").New("new-secret").Do()
+			if err != nil {
+				if e, ok := err.(*account.ErrorResponse); ok {
+					assert.Equal(t, tc.wantErrCode, e.Code, "An error was expected")
+				} else {
+					t.Fatalf("%v, unexpected error", err)
+				}
+			} else {
+				assert.Zero(t, tc.wantErrCode, "An error was expected")
+			}
+
+			assert.Equal(t, recordedRequest{
+				Method: http.MethodPut,
+				Path:   "/v1.1/me/password",
+				Body:   map[string]string{"old_password": "old-secret", "new_password": "new-secret"},
+			}, *got)
+		})
+	}
+}
+
+func TestPasswordReset_Do(t *testing.T) {
+	tt := []struct {
+		name        string
+		givenStatus int
+		givenBody   string
+		givenCall   func(s *account.Service) error
+		wantErrCode account.ErrorCode
+		wantRequest recordedRequest
+	}{
+		{
+			name:        "request reset email",
+			givenStatus: http.StatusOK,
+			givenBody:   `{"message":"OK","code":0}`,
+			givenCall: func(s *account.Service) error {
+				return s.Me.Password.RequestReset().Email("gary@example.com").Do()
+			},
+			wantRequest: recordedRequest{
+				Method: http.MethodPost,
+				Path:   "/v1.1/password/reset",
+				Body:   map[string]string{"email": "gary@example.com"},
+			},
+		},
+		{
+			name:        "confirm reset",
+			givenStatus: http.StatusOK,
+			givenBody:   `{"message":"OK","code":0}`,
+			givenCall: func(s *account.Service) error {
+				return s.Me.Password.ConfirmReset().Token("reset-token").New("new-secret").Do()
+			},
+			wantRequest: recordedRequest{
+				Method: http.MethodPost,
+				Path:   "/v1.1/password/reset/confirm",
+				Body:   map[string]string{"token": "reset-token", "new_password": "new-secret"},
+			},
+		},
+		{
+			name:        "fail confirm with invalid token",
+			givenStatus: http.StatusBadRequest,
+			givenBody:   `{"message":"invalid token","code":40012}`,
+			givenCall: func(s *account.Service) error {
+				return s.Me.Password.ConfirmReset().Token("expired").New("new-secret").Do()
+			},
+			wantErrCode: account.ErrorCodeInvalidResetToken,
+			wantRequest: recordedRequest{
+				Method: http.MethodPost,
+				Path:   "/v1.1/password/reset/confirm",
+				Body:   map[string]string{"token": "expired", "new_password": "new-secret"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ts, got := newRecordingServer(t, tc.givenStatus, tc.givenBody)
+			defer ts.Close()
+
+			s := account.New(ts.Client(), account.WithBaseURL(ts.URL))
+			err := tc.givenCall(s)
+			if err != nil {
+				if e, ok := err.(*account.ErrorResponse); ok {
+					assert.Equal(t, tc.wantErrCode, e.Code, "An error was expected")
+				} else {
+					t.Fatalf("%v, unexpected error", err)
+				}
+			} else {
+				assert.Zero(t, tc.wantErrCode, "An error was expected")
+			}
+			assert.Equal(t, tc.wantRequest, *got)
+		})
+	}
+}
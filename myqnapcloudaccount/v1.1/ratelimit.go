@@ -0,0 +1,60 @@
+package account
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// WithRateLimit limits the Service to r requests per second, allowing bursts
+// of up to burst requests. Calls wait for a token before being sent.
+func WithRateLimit(r float64, burst int) Option {
+	return WithLimiter(rate.NewLimiter(rate.Limit(r), burst))
+}
+
+// WithLimiter makes the Service wait on l before sending each request.
+// Pass the same limiter to several Services to make them share one budget.
+func WithLimiter(l *rate.Limiter) Option {
+	return func(s *Service) {
+		s.limiter = l
+	}
+}
+
+// A RateLimitError is returned when the API answers with 429 Too Many Requests.
+type RateLimitError struct {
+	*ErrorResponse
+
+	Limit     int       // requests allowed in the current window, if reported
+	Remaining int       // requests left in the current window, if reported
+	Reset     time.Time // when the window resets, zero if unknown
+}
+
+func newRateLimitError(r *ErrorResponse) *RateLimitError {
+	e := &RateLimitError{ErrorResponse: r}
+	h := r.HttpResponse.Header
+
+	e.Limit, _ = strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	e.Remaining, _ = strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		e.Reset = time.Unix(reset, 0)
+	} else if after, ok := retryAfter(h.Get("Retry-After")); ok {
+		e.Reset = time.Now().Add(after)
+	}
+	return e
+}
+
+// Error implements the error interface.
+func (r *RateLimitError) Error() string {
+	msg := r.ErrorResponse.Error()
+	if !r.Reset.IsZero() {
+		msg = fmt.Sprintf("%s, rate limit resets at %s", msg, r.Reset.Format(time.RFC3339))
+	}
+	return msg
+}
+
+// Unwrap returns the underlying ErrorResponse, for use with errors.As.
+func (r *RateLimitError) Unwrap() error {
+	return r.ErrorResponse
+}
@@ -0,0 +1,104 @@
+package account_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+)
+
+func TestService_RateLimit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":"OK","code":0,"result":{}}`))
+	}))
+	defer ts.Close()
+
+	// 50 req/s with no burst spaces requests 20ms apart
+	shared := rate.NewLimiter(50, 1)
+	a := account.New(ts.Client(), account.WithBaseURL(ts.URL), account.WithLimiter(shared))
+	b := account.New(ts.Client(), account.WithBaseURL(ts.URL), account.WithLimiter(shared))
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		s := a
+		if i%2 == 1 {
+			s = b
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Me.Get().Do()
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.True(t, time.Since(start) >= 100*time.Millisecond, "requests were expected to be throttled")
+}
+
+func TestCheckResponse_RateLimitError(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Truncate(time.Second)
+
+	tt := []struct {
+		name          string
+		givenHeader   http.Header
+		givenBody     string
+		wantLimit     int
+		wantRemaining int
+		wantReset     time.Time
+		wantCode      account.ErrorCode
+	}{
+		{
+			name: "rate limit headers",
+			givenHeader: http.Header{
+				"X-Ratelimit-Limit":     {"100"},
+				"X-Ratelimit-Remaining": {"0"},
+				"X-Ratelimit-Reset":     {strconv.FormatInt(reset.Unix(), 10)},
+			},
+			givenBody:     `{"message":"too many requests","code":42900}`,
+			wantLimit:     100,
+			wantRemaining: 0,
+			wantReset:     reset,
+			wantCode:      42900,
+		},
+		{
+			name:        "retry after and non json body",
+			givenHeader: http.Header{"Retry-After": {"60"}},
+			givenBody:   `<html>slow down</html>`,
+			wantReset:   reset,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tc.givenHeader {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(tc.givenBody))
+			}))
+			defer ts.Close()
+
+			s := account.New(ts.Client(), account.WithBaseURL(ts.URL))
+			_, err := s.Me.Get().Do()
+			if e, ok := err.(*account.RateLimitError); ok {
+				assert.Equal(t, tc.wantLimit, e.Limit)
+				assert.Equal(t, tc.wantRemaining, e.Remaining)
+				assert.WithinDuration(t, tc.wantReset, e.Reset, 2*time.Second)
+				assert.Equal(t, tc.wantCode, e.Code)
+				assert.Equal(t, http.StatusTooManyRequests, e.HttpResponse.StatusCode)
+			} else {
+				t.Fatalf("%v, unexpected error", err)
+			}
+		})
+	}
+}
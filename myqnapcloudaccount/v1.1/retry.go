@@ -0,0 +1,166 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how Service retries requests failing with a transport
+// error or a retryable status code. The zero value disables retries.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+
+	// MinBackoff and MaxBackoff bound the exponential backoff between attempts.
+	// A random jitter of up to half the backoff is subtracted from each wait.
+	// A Retry-After longer than MaxBackoff ends the retries instead.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// RetryableStatus lists the HTTP status codes worth retrying.
+	RetryableStatus []int
+
+	// RetryNonIdempotent also retries POST and PATCH requests, which may have
+	// been applied by the server before the failure was reported. Calls that
+	// cannot be replayed, like a password change, are never retried.
+	RetryNonIdempotent bool
+}
+
+type noRetryKey struct{}
+
+// withoutRetry marks ctx as the context of a call whose requests are never
+// retried, because a replay fails once the first attempt was applied.
+func withoutRetry(ctx context.Context) context.Context {
+	return context.WithValue(contextOrBackground(ctx), noRetryKey{}, true)
+}
+
+func replayable(ctx context.Context) bool {
+	noRetry, _ := ctx.Value(noRetryKey{}).(bool)
+	return !noRetry
+}
+
+// DefaultRetryPolicy retries idempotent requests up to three times on
+// transport errors, throttling and gateway failures.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  200 * time.Millisecond,
+	MaxBackoff:  5 * time.Second,
+	RetryableStatus: []int{
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	},
+}
+
+// WithRetryPolicy makes the Service retry failed requests according to p.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(s *Service) {
+		s.retryPolicy = p
+	}
+}
+
+// backoff reports whether the attempt-th try of req, which ended with resp or
+// err, should be retried and how long to wait before doing so.
+func (p RetryPolicy) backoff(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || req.Context().Err() != nil {
+		return 0, false
+	}
+	if !p.RetryNonIdempotent && (req.Method == "POST" || req.Method == "PATCH") {
+		return 0, false
+	}
+	if !replayable(req.Context()) {
+		return 0, false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return 0, false
+	}
+
+	if err == nil && !p.retryableStatus(resp.StatusCode) {
+		return 0, false
+	}
+
+	d := p.MinBackoff << uint(attempt-1)
+	if d > p.MaxBackoff || d <= 0 {
+		d = p.MaxBackoff
+	}
+	if d > 0 {
+		d -= time.Duration(rand.Int63n(int64(d)/2 + 1))
+	}
+
+	if resp != nil {
+		if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok && after > d {
+			if after > p.MaxBackoff {
+				return 0, false
+			}
+			d = after
+		}
+	}
+	return d, true
+}
+
+func (p RetryPolicy) retryableStatus(code int) bool {
+	for _, c := range p.RetryableStatus {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
+
+// rewind waits for delay and returns a copy of req ready to be sent again,
+// with its body rewound to the start.
+func rewind(req *http.Request, delay time.Duration) (*http.Request, error) {
+	ctx := req.Context()
+	if err := sleep(ctx, delay); err != nil {
+		return nil, err
+	}
+
+	r := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	} else if req.Body != nil && req.Body != http.NoBody {
+		return nil, errors.New("request body cannot be rewound")
+	}
+	return r, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,202 @@
+package account_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+)
+
+var testRetryPolicy = account.RetryPolicy{
+	MaxAttempts:     3,
+	MinBackoff:      time.Millisecond,
+	MaxBackoff:      5 * time.Millisecond,
+	RetryableStatus: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusBadGateway},
+}
+
+func TestService_Retry(t *testing.T) {
+	nonIdempotent := testRetryPolicy
+	nonIdempotent.RetryNonIdempotent = true
+
+	tt := []struct {
+		name         string
+		givenPolicy  account.RetryPolicy
+		givenFailing int
+		givenStatus  int
+		givenCall    func(s *account.Service) error
+		wantAttempts int32
+		wantError    bool
+	}{
+		{
+			name:         "get succeeds after transient failures",
+			givenPolicy:  testRetryPolicy,
+			givenFailing: 2,
+			givenStatus:  http.StatusServiceUnavailable,
+			givenCall:    func(s *account.Service) error { _, err := s.Me.Get().Do(); return err },
+			wantAttempts: 3,
+		},
+		{
+			name:         "get fails when attempts are exhausted",
+			givenPolicy:  testRetryPolicy,
+			givenFailing: 3,
+			givenStatus:  http.StatusServiceUnavailable,
+			givenCall:    func(s *account.Service) error { _, err := s.Me.Get().Do(); return err },
+			wantAttempts: 3,
+			wantError:    true,
+		},
+		{
+			name:         "put body is rewound between attempts",
+			givenPolicy:  testRetryPolicy,
+			givenFailing: 1,
+			givenStatus:  http.StatusServiceUnavailable,
+			givenCall: func(s *account.Service) error {
+				_, err := s.Me.Avatar.Upload(strings.NewReader("\x89PNG fake image"), "image/png").Do()
+				return err
+			},
+			wantAttempts: 2,
+		},
+		{
+			name:         "password change is never retried",
+			givenPolicy:  nonIdempotent,
+			givenFailing: 1,
+			givenStatus:  http.StatusBadGateway,
+			givenCall:    func(s *account.Service) error { return s.Me.Password.Change().Old("a").New("b").Do() },
+			wantAttempts: 1,
+			wantError:    true,
+		},
+		{
+			name:         "status not retryable",
+			givenPolicy:  testRetryPolicy,
+			givenFailing: 1,
+			givenStatus:  http.StatusInternalServerError,
+			givenCall:    func(s *account.Service) error { _, err := s.Me.Get().Do(); return err },
+			wantAttempts: 1,
+			wantError:    true,
+		},
+		{
+			name:         "post is not retried by default",
+			givenPolicy:  testRetryPolicy,
+			givenFailing: 1,
+			givenStatus:  http.StatusServiceUnavailable,
+			givenCall:    func(s *account.Service) error { _, err := s.Friend.Invite("a@example.com").Do(); return err },
+			wantAttempts: 1,
+			wantError:    true,
+		},
+		{
+			name:         "post is retried when allowed",
+			givenPolicy:  nonIdempotent,
+			givenFailing: 1,
+			givenStatus:  http.StatusServiceUnavailable,
+			givenCall:    func(s *account.Service) error { _, err := s.Friend.Invite("a@example.com").Do(); return err },
+			wantAttempts: 2,
+		},
+		{
+			name:         "no retries without policy",
+			givenFailing: 1,
+			givenStatus:  http.StatusServiceUnavailable,
+			givenCall:    func(s *account.Service) error { _, err := s.Me.Get().Do(); return err },
+			wantAttempts: 1,
+			wantError:    true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts int32
+			var firstBody string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&attempts, 1)
+				body, _ := io.ReadAll(r.Body)
+				if n == 1 {
+					firstBody = string(body)
+				} else {
+					assert.Equal(t, firstBody, string(body), "body was expected to be rewound")
+				}
+				if int(n) <= tc.givenFailing {
+					w.WriteHeader(tc.givenStatus)
+					w.Write([]byte(`{"message":"try again","code":50300}`))
+					return
+				}
+				w.Write([]byte(`{"message":"OK","code":0,"result":{}}`))
+			}))
+			defer ts.Close()
+
+			s := account.New(ts.Client(), account.WithBaseURL(ts.URL), account.WithRetryPolicy(tc.givenPolicy))
+			err := tc.givenCall(s)
+			if tc.wantError {
+				assert.IsType(t, &account.ErrorResponse{}, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantAttempts, atomic.LoadInt32(&attempts))
+		})
+	}
+}
+
+func TestService_RetryHonoursRetryAfter(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"message":"OK","code":0,"result":{}}`))
+	}))
+	defer ts.Close()
+
+	policy := testRetryPolicy
+	policy.MaxBackoff = 2 * time.Second
+	s := account.New(ts.Client(), account.WithBaseURL(ts.URL), account.WithRetryPolicy(policy))
+	start := time.Now()
+	_, err := s.Me.Get().Do()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+	assert.True(t, time.Since(start) >= time.Second, "Retry-After was expected to be honoured")
+}
+
+func TestService_RetryAfterBeyondMaxBackoff(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	s := account.New(ts.Client(), account.WithBaseURL(ts.URL), account.WithRetryPolicy(testRetryPolicy))
+	start := time.Now()
+	_, err := s.Me.Get().Do()
+	assert.IsType(t, &account.RateLimitError{}, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+	assert.True(t, time.Since(start) < time.Second, "a Retry-After beyond MaxBackoff was expected to end the retries")
+}
+
+func TestService_RetryTransportError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.Close()
+
+	var attempts int32
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&attempts, 1)
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+
+	s := account.New(client, account.WithBaseURL(ts.URL), account.WithRetryPolicy(testRetryPolicy))
+	_, err := s.Me.Get().Do()
+	assert.Error(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,84 @@
+package account
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const instrumentationName = "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+
+// WithTracerProvider makes the Service record a client span for every API
+// call with tracers from tp, and inject the span context into the request
+// headers. Without it no spans are recorded.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(s *Service) {
+		s.tracer = tp.Tracer(instrumentationName)
+	}
+}
+
+// WithPropagator sets the propagator injecting the span context into request
+// headers. It defaults to the global otel.GetTextMapPropagator.
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(s *Service) {
+		s.propagator = p
+	}
+}
+
+// startSpan starts the span of an API call and returns req with the span in
+// its context and the span context in its headers.
+func (c *Service) startSpan(req *http.Request) (*http.Request, trace.Span) {
+	tracer := c.tracer
+	if tracer == nil {
+		tracer = noop.NewTracerProvider().Tracer(instrumentationName)
+	}
+	// spans are named after the route template, never the path, which
+	// carries user IDs
+	name := req.Method
+	attrs := []attribute.KeyValue{
+		attribute.String("myqnapcloud.operation", Operation(req.Context())),
+		attribute.String("http.request.method", req.Method),
+		attribute.String("server.address", req.URL.Host),
+	}
+	if t := routeTemplate(req.Context()); t != "" {
+		route := c.versioned(t)
+		name = fmt.Sprintf("%s %s", req.Method, route)
+		attrs = append(attrs, attribute.String("url.template", route))
+	}
+
+	ctx, span := tracer.Start(req.Context(), name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	if c.tracer == nil {
+		return req, span
+	}
+
+	propagator := c.propagator
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+	req = req.WithContext(ctx)
+	propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	return req, span
+}
+
+// endSpan records the outcome of an API call on span and ends it.
+func endSpan(span trace.Span, resp *http.Response, err error) {
+	if resp != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if resp != nil && resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	span.End()
+}
@@ -0,0 +1,78 @@
+package account_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+)
+
+func TestWithTracerProvider(t *testing.T) {
+	var gotTraceparent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceparent = r.Header.Get("Traceparent")
+		if r.URL.Path == "/v1.1/friends/u1" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"friend not found","code":40400}`))
+			return
+		}
+		w.Write([]byte(`{"message":"OK","code":0,"result":{"user_id":"u1"}}`))
+	}))
+	defer ts.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	s := account.New(ts.Client(), account.WithBaseURL(ts.URL),
+		account.WithTracerProvider(tp), account.WithPropagator(propagation.TraceContext{}))
+
+	tt := []struct {
+		name       string
+		givenCall  func(ctx context.Context) error
+		wantName   string
+		wantStatus int
+		wantCode   codes.Code
+	}{
+		{
+			name:       "me get",
+			givenCall:  func(ctx context.Context) error { _, err := s.Me.Get().Context(ctx).Do(); return err },
+			wantName:   "GET /v1.1/me",
+			wantStatus: http.StatusOK,
+			wantCode:   codes.Unset,
+		},
+		{
+			name:       "friend remove not found",
+			givenCall:  func(ctx context.Context) error { return s.Friend.Remove("u1").Context(ctx).Do() },
+			wantName:   "DELETE /v1.1/friends/{user_id}",
+			wantStatus: http.StatusNotFound,
+			wantCode:   codes.Error,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			exporter.Reset()
+			ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+			tc.givenCall(ctx)
+			parent.End()
+
+			spans := exporter.GetSpans()
+			if assert.Len(t, spans, 2) {
+				got := spans[0]
+				assert.Equal(t, tc.wantName, got.Name)
+				assert.Equal(t, parent.SpanContext().SpanID(), got.Parent.SpanID())
+				assert.Equal(t, tc.wantCode, got.Status.Code)
+				assert.Contains(t, got.Attributes, attribute.Int("http.response.status_code", tc.wantStatus))
+				assert.Contains(t, gotTraceparent, got.SpanContext.SpanID().String())
+			}
+		})
+	}
+}
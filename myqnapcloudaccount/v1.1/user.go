@@ -0,0 +1,88 @@
+package account
+
+import (
+	"context"
+	"net/url"
+)
+
+// PublicProfile is the part of a myQNAPcloud account visible to other users.
+type PublicProfile struct {
+	UserId      string  `json:"user_id"`
+	DisplayName string  `json:"display_name"`
+	Avatars     Avatars `json:"avatars"`
+}
+
+type UserService struct {
+	s *Service
+}
+
+func NewUserService(s *Service) *UserService {
+	rs := &UserService{s: s}
+	return rs
+}
+
+// User get call
+type UserGetCall struct {
+	s   *Service
+	ctx context.Context
+
+	userId string
+}
+
+// Get looks up the public profile of the account with the given user ID.
+func (r *UserService) Get(userId string) *UserGetCall {
+	c := &UserGetCall{s: r.s, userId: userId}
+	return c
+}
+
+// Context sets the context to be used in this call's Do method.
+// Any pending HTTP request will be aborted if the provided context is canceled.
+func (c *UserGetCall) Context(ctx context.Context) *UserGetCall {
+	c.ctx = ctx
+	return c
+}
+
+func (c *UserGetCall) Do() (*PublicProfile, error) {
+	return c.DoContext(c.ctx)
+}
+
+// DoContext executes the call bound to ctx, overriding any context set with Context.
+func (c *UserGetCall) DoContext(ctx context.Context) (*PublicProfile, error) {
+	path := c.s.versioned("users/" + url.PathEscape(c.userId))
+	env := &Envelope[PublicProfile]{}
+	resp, err := c.s.get(withOperation(ctx, "user.get", "users/{user_id}"), path, env)
+	return unwrap(resp, env, err)
+}
+
+// User find by email call
+type UserFindByEmailCall struct {
+	s   *Service
+	ctx context.Context
+
+	email string
+}
+
+// FindByEmail looks up the public profile of the account registered with email.
+func (r *UserService) FindByEmail(email string) *UserFindByEmailCall {
+	c := &UserFindByEmailCall{s: r.s, email: email}
+	return c
+}
+
+// Context sets the context to be used in this call's Do method.
+// Any pending HTTP request will be aborted if the provided context is canceled.
+func (c *UserFindByEmailCall) Context(ctx context.Context) *UserFindByEmailCall {
+	c.ctx = ctx
+	return c
+}
+
+func (c *UserFindByEmailCall) Do() (*PublicProfile, error) {
+	return c.DoContext(c.ctx)
+}
+
+// DoContext executes the call bound to ctx, overriding any context set with Context.
+func (c *UserFindByEmailCall) DoContext(ctx context.Context) (*PublicProfile, error) {
+	path := withQuery(c.s.versioned("users"), url.Values{"email": {c.email}})
+	env := &Envelope[PublicProfile]{}
+	resp, err := c.s.get(withOperation(ctx, "user.find_by_email", "users"), path, env)
+	return unwrap(resp, env, err)
+}
@@ -0,0 +1,91 @@
+package account_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	account "github.com/qeek-dev/qeek-api-go-client/myqnapcloudaccount/v1.1"
+)
+
+func TestUserService_Lookup(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1.1/users/u1",
+			r.URL.Path == "/v1.1/users" && r.URL.Query().Get("email") == "gary+test@example.com":
+			w.Write([]byte(`{"message":"OK","code":0,"result":{"user_id":"u1","display_name":"Gary","avatars":{"small":"https://a/s.jpg","cn":{"icon":"https://cn/i.jpg"}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"user not found","code":40400}`))
+		}
+	}))
+	defer ts.Close()
+
+	s := account.New(ts.Client(), account.WithBaseURL(ts.URL))
+
+	wantProfile := account.PublicProfile{
+		UserId:      "u1",
+		DisplayName: "Gary",
+		Avatars: account.Avatars{
+			Small: "https://a/s.jpg",
+			Cn:    account.AvatarUrls{Icon: "https://cn/i.jpg"},
+		},
+	}
+
+	tt := []struct {
+		name        string
+		givenCall   func() (*account.PublicProfile, error)
+		wantProfile account.PublicProfile
+		wantError   bool
+	}{
+		{
+			name:        "get by id",
+			givenCall:   s.User.Get("u1").Do,
+			wantProfile: wantProfile,
+		},
+		{
+			name:        "find by email",
+			givenCall:   s.User.FindByEmail("gary+test@example.com").Do,
+			wantProfile: wantProfile,
+		},
+		{
+			name:      "fail get with unknown id",
+			givenCall: s.User.Get("u2").Do,
+			wantError: true,
+		},
+		{
+			name:      "fail find with unknown email",
+			givenCall: s.User.FindByEmail("nobody@example.com").Do,
+			wantError: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := tc.givenCall()
+			if tc.wantError {
+				assert.IsType(t, &account.ErrorResponse{}, err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tc.wantProfile, *res)
+			}
+		})
+	}
+}
+
+func TestMeGetCall_DoSharesPublicProfile(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":"OK","code":0,"result":{"user_id":"u1","display_name":"Gary","email":"gary@example.com","first_name":"Gary","avatars":{"medium":"https://a/m.jpg"}}}`))
+	}))
+	defer ts.Close()
+
+	s := account.New(ts.Client(), account.WithBaseURL(ts.URL))
+	res, err := s.Me.Get().Do()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "u1", res.UserId)
+		assert.Equal(t, "Gary", res.DisplayName)
+		assert.Equal(t, "https://a/m.jpg", res.Avatars.Medium)
+		assert.Equal(t, "gary@example.com", res.Email)
+	}
+}
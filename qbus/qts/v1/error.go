@@ -6,3 +6,12 @@ const (
 	QtsErrorBadRequest    QtsErrCode = iota + 40000
 	QtsErrorInternalError QtsErrCode = iota + 50000
 )
+
+// Qbus error codes reported in the errorCode of failed qbus responses, see
+// QtsErr.QbusCode.
+const (
+	QbusErrorSidNotSpecified = 4000200 // 'sid' is not specified or not found
+	QbusErrorInvalidSid      = 4000201 // NAS sid is not valid, e.g. expired
+	QbusErrorUserNotExist    = 4000202 // no user with the given name
+	QbusErrorAuthFailed      = 4000203 // wrong user name or password
+)
@@ -0,0 +1,68 @@
+package qts
+
+import (
+	"bytes"
+	"context"
+	"syscall"
+
+	"github.com/codeskyblue/go-sh"
+)
+
+// Executor runs a qbus command, e.g. verb "get" on path
+// "com.qnap.dj2/qts/users" with a JSON payload, and returns its JSON output.
+type Executor interface {
+	Exec(ctx context.Context, verb, path string, payload []byte) ([]byte, error)
+}
+
+// ExecutorFunc adapts a function to an Executor.
+type ExecutorFunc func(ctx context.Context, verb, path string, payload []byte) ([]byte, error)
+
+// Exec calls f(ctx, verb, path, payload).
+func (f ExecutorFunc) Exec(ctx context.Context, verb, path string, payload []byte) ([]byte, error) {
+	return f(ctx, verb, path, payload)
+}
+
+// CommandExecutor runs the qbus binary with go-sh. It is the default
+// Executor of NewClient. The command is killed when ctx is done.
+type CommandExecutor struct {
+	// Path of the qbus binary, "qbus" looked up in PATH when empty.
+	Path string
+}
+
+// Exec implements Executor.
+func (e CommandExecutor) Exec(ctx context.Context, verb, path string, payload []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	name := e.Path
+	if name == "" {
+		name = "qbus"
+	}
+	// go-sh sessions are not safe for concurrent use, use one per command
+	var out bytes.Buffer
+	s := sh.NewSession()
+	s.ShowCMD = false
+	s.Stdout = &out
+	if err := s.Command(name, verb, path, string(payload)).Start(); err != nil {
+		return nil, err
+	}
+
+	done := sh.Go(s.Wait)
+	select {
+	case err := <-done:
+		return out.Bytes(), err
+	case <-ctx.Done():
+		// the command is reaped by the Wait goroutine, which may outlive Exec
+		// while a child of the command holds its output open
+		s.Kill(syscall.SIGKILL)
+		return nil, ctx.Err()
+	}
+}
+
+// WithExecutor makes the Service run qbus commands with e instead of the
+// qbus binary.
+func WithExecutor(e Executor) Option {
+	return func(s *Service) {
+		s.executor = e
+	}
+}
@@ -0,0 +1,32 @@
+package qts
+
+import (
+	"strconv"
+
+	"github.com/qeek-dev/qeek-api-go-client/metrics"
+)
+
+// WithMetrics makes the Service record the count, latency and concurrency of
+// its qbus commands on c. The code label is the QbusCode of failed commands,
+// the QtsErrCode when qbus could not be run or its output not decoded, and
+// the response code otherwise.
+func WithMetrics(c *metrics.Collector) Option {
+	return func(s *Service) {
+		s.metrics = c
+	}
+}
+
+// outcome returns the metrics outcome and code label of a qbus command.
+func outcome(out pointer, err error) (metrics.Outcome, string) {
+	if err != nil {
+		return metrics.Failure, strconv.Itoa(int(QtsErrorInternalError))
+	}
+	if r, ok := out.(qbusResponse); ok {
+		res := r.response()
+		if res.Code != 200 {
+			return metrics.Failure, strconv.Itoa(res.ErrorCode)
+		}
+		return metrics.Success, strconv.Itoa(res.Code)
+	}
+	return metrics.Success, ""
+}
@@ -1,13 +1,17 @@
 package qts
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"reflect"
 
-	"github.com/codeskyblue/go-sh"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/qeek-dev/qeek-api-go-client/internal/redact"
+	"github.com/qeek-dev/qeek-api-go-client/metrics"
 )
 
 type QtsErr struct {
@@ -103,33 +107,88 @@ type NasUserAvatarResult struct {
 }
 
 type Service struct {
-	s             *sh.Session
+	executor      Executor
 	sid           string
 	qbusNameSpace string
+	debug         bool
+	logger        *slog.Logger
+	tracer        trace.Tracer
+	metrics       *metrics.Collector
+}
+
+// logError logs err with its Qts and Qbus codes as separate fields.
+func (s *Service) logError(err error) error {
+	attrs := []slog.Attr{slog.String("err", err.Error())}
+	if qe, ok := err.(*QtsErr); ok {
+		attrs = append(attrs, slog.Int("code", int(qe.Code)))
+		if qe.Err == nil {
+			attrs = append(attrs, slog.Int("qbus_code", qe.QbusCode), slog.String("qbus_err", qe.QbusErr))
+		}
+	}
+	s.logger.LogAttrs(context.Background(), slog.LevelError, "qts call failed", attrs...)
+	return err
 }
 
-func logError(err error) error {
-	log.Println(err)
-	return err
+// logCommand logs the qbus command with sid and pwd redacted from the
+// payload, at info level in debug mode and at debug level otherwise.
+func (s *Service) logCommand(ctx context.Context, verb, path string, payload []byte) {
+	level := slog.LevelDebug
+	if s.debug {
+		level = slog.LevelInfo
+	}
+	s.logger.LogAttrs(ctx, level, "qbus command",
+		slog.String("verb", verb),
+		slog.String("path", path),
+		slog.String("payload", redact.JSON(payload)),
+	)
 }
 
-func (s *Service) exec(out pointer, cmd ...interface{}) error {
+// exec runs the qbus command verb on the path under the namespace of the
+// Service with the JSON encoding of payload, for the operation op, e.g.
+// "qts.login", and decodes its output into out.
+func (s *Service) exec(ctx context.Context, out pointer, op, verb, path string, payload interface{}) (err error) {
 	if !isPointer(out) {
 		return errors.New(fmt.Sprintf("Value '%s' is not a pointer", out))
 	}
-	o, err := s.s.Command("qbus", cmd...).Output()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	path = s.qbusNameSpace + "/" + path
+	ctx, span := s.startSpan(ctx, op, verb, path)
+	done := s.metrics.Start("qts", op)
+	defer func() {
+		endSpan(span, out, err)
+		done(outcome(out, err))
+	}()
+
+	p, err := json.Marshal(payload)
+	if err != nil {
+		return s.logError(errors.Wrap(err, "qbus payload marshal fail"))
+	}
+	s.logCommand(ctx, verb, path, p)
+	o, err := s.executor.Exec(ctx, verb, path, p)
 	if err != nil {
-		return logError(errors.Wrap(err, "qbus command exec fail"))
+		return s.logError(errors.Wrap(err, "qbus command exec fail"))
 	}
 
 	err = json.Unmarshal(o, &out)
 	if err != nil {
-		return logError(errors.Wrap(err, "qbus json unmarshal fail"))
+		return s.logError(errors.Wrap(err, "qbus json unmarshal fail"))
 	}
 
 	return nil
 }
 
+// sidPayload is the payload of the commands authenticated with a sid.
+type sidPayload struct {
+	Sid string `json:"sid"`
+}
+
+type loginPayload struct {
+	User string `json:"user"`
+	Pwd  string `json:"pwd"`
+}
+
 func (l *Service) GetSid() string {
 	return l.sid
 }
@@ -138,36 +197,50 @@ func (l *Service) GetSid() string {
 type NasMeCall struct {
 	s        *Service
 	username string
+	ctx      context.Context
 }
 
 func (l *Service) Me() *NasMeCall {
-	return &NasMeCall{l, ""}
+	return &NasMeCall{l, "", nil}
+}
+
+// Context sets the context of the call, the parent of its span.
+func (l *NasMeCall) Context(ctx context.Context) *NasMeCall {
+	l.ctx = ctx
+	return l
 }
 
 func (l *NasMeCall) Do() (r NasUserResult, err error) {
 	var out NasMeResponse
-	err = l.s.exec(&out, "get", fmt.Sprintf("%s/qts/user/me", l.s.qbusNameSpace), fmt.Sprintf(`{"sid":"%s"}`, l.s.sid))
+	err = l.s.exec(l.ctx, &out, "qts.me", "get", "qts/user/me", sidPayload{l.s.sid})
 	if err != nil {
-		err = logError(&QtsErr{Code: QtsErrorInternalError, Err: err})
+		err = l.s.logError(&QtsErr{Code: QtsErrorInternalError, Err: err})
 	} else if out.Code != 200 {
-		err = logError(&QtsErr{Code: QtsErrorBadRequest, QbusCode: out.ErrorCode, QbusErr: out.ErrorMsg})
+		err = l.s.logError(&QtsErr{Code: QtsErrorBadRequest, QbusCode: out.ErrorCode, QbusErr: out.ErrorMsg})
 	}
 
 	if err != nil {
 		return
 	}
 
-	return l.s.User().UserName(out.Result.User).Do()
+	return l.s.User().Context(l.ctx).UserName(out.Result.User).Do()
 }
 
 // Nas user call
 type NasUserCall struct {
 	s        *Service
 	username string
+	ctx      context.Context
 }
 
 func (l *Service) User() *NasUserCall {
-	return &NasUserCall{l, ""}
+	return &NasUserCall{l, "", nil}
+}
+
+// Context sets the context of the call, the parent of its span.
+func (l *NasUserCall) Context(ctx context.Context) *NasUserCall {
+	l.ctx = ctx
+	return l
 }
 
 func (l *NasUserCall) UserName(username string) *NasUserCall {
@@ -177,12 +250,12 @@ func (l *NasUserCall) UserName(username string) *NasUserCall {
 
 func (l *NasUserCall) Do() (r NasUserResult, err error) {
 	var out NasUserResponse
-	err = l.s.exec(&out, "get", fmt.Sprintf("%s/qts/user/%s", l.s.qbusNameSpace, l.username), fmt.Sprintf(`{"sid":"%s"}`, l.s.sid))
+	err = l.s.exec(l.ctx, &out, "qts.user", "get", "qts/user/"+l.username, sidPayload{l.s.sid})
 	if err != nil {
-		err = logError(&QtsErr{Code: QtsErrorInternalError, Err: err})
+		err = l.s.logError(&QtsErr{Code: QtsErrorInternalError, Err: err})
 	} else {
 		if out.Code != 200 {
-			err = logError(&QtsErr{Code: QtsErrorBadRequest, QbusCode: out.ErrorCode, QbusErr: out.ErrorMsg})
+			err = l.s.logError(&QtsErr{Code: QtsErrorBadRequest, QbusCode: out.ErrorCode, QbusErr: out.ErrorMsg})
 		} else {
 			r = out.Result
 		}
@@ -192,21 +265,28 @@ func (l *NasUserCall) Do() (r NasUserResult, err error) {
 
 // Nas users call
 type NasUsersCall struct {
-	s *Service
+	s   *Service
+	ctx context.Context
 }
 
 func (l *Service) Users() *NasUsersCall {
-	return &NasUsersCall{l}
+	return &NasUsersCall{l, nil}
+}
+
+// Context sets the context of the call, the parent of its span.
+func (l *NasUsersCall) Context(ctx context.Context) *NasUsersCall {
+	l.ctx = ctx
+	return l
 }
 
 func (l *NasUsersCall) Do() (r []NasUserResult, err error) {
 	var out NasUsersResponse
-	err = l.s.exec(&out, "get", fmt.Sprintf("%s/qts/users", l.s.qbusNameSpace), fmt.Sprintf(`{"sid":"%s"}`, l.s.sid))
+	err = l.s.exec(l.ctx, &out, "qts.users", "get", "qts/users", sidPayload{l.s.sid})
 	if err != nil {
-		err = logError(&QtsErr{Code: QtsErrorInternalError, Err: err})
+		err = l.s.logError(&QtsErr{Code: QtsErrorInternalError, Err: err})
 	} else {
 		if out.Code != 200 {
-			err = logError(&QtsErr{Code: QtsErrorBadRequest, QbusCode: out.ErrorCode, QbusErr: out.ErrorMsg})
+			err = l.s.logError(&QtsErr{Code: QtsErrorBadRequest, QbusCode: out.ErrorCode, QbusErr: out.ErrorMsg})
 		} else {
 			r = out.Result
 		}
@@ -216,11 +296,18 @@ func (l *NasUsersCall) Do() (r []NasUserResult, err error) {
 
 // verify sid call
 type VerifySidCall struct {
-	s *Service
+	s   *Service
+	ctx context.Context
 }
 
 func (l *Service) Verify() *VerifySidCall {
-	return &VerifySidCall{l}
+	return &VerifySidCall{l, nil}
+}
+
+// Context sets the context of the call, the parent of its span.
+func (l *VerifySidCall) Context(ctx context.Context) *VerifySidCall {
+	l.ctx = ctx
+	return l
 }
 
 func (l *VerifySidCall) Sid(sid string) *VerifySidCall {
@@ -230,12 +317,12 @@ func (l *VerifySidCall) Sid(sid string) *VerifySidCall {
 
 func (l *VerifySidCall) Do() (err error) {
 	var out VerifySidResponse
-	err = l.s.exec(&out, "get", fmt.Sprintf("%s/qts/verify_sid", l.s.qbusNameSpace), fmt.Sprintf(`{"sid":"%s"}`, l.s.sid))
+	err = l.s.exec(l.ctx, &out, "qts.verify_sid", "get", "qts/verify_sid", sidPayload{l.s.sid})
 	if err != nil || out.Code != 200 {
 		if err != nil {
-			err = logError(&QtsErr{Code: QtsErrorInternalError, Err: err})
+			err = l.s.logError(&QtsErr{Code: QtsErrorInternalError, Err: err})
 		} else {
-			err = logError(&QtsErr{Code: QtsErrorBadRequest, QbusCode: out.ErrorCode, QbusErr: out.ErrorMsg})
+			err = l.s.logError(&QtsErr{Code: QtsErrorBadRequest, QbusCode: out.ErrorCode, QbusErr: out.ErrorMsg})
 		}
 		// clear sid
 		l.s.sid = ""
@@ -248,10 +335,17 @@ type LoginCall struct {
 	s        *Service
 	username string
 	password string
+	ctx      context.Context
 }
 
 func (l *Service) Login() *LoginCall {
-	return &LoginCall{l, "", ""}
+	return &LoginCall{l, "", "", nil}
+}
+
+// Context sets the context of the call, the parent of its span.
+func (l *LoginCall) Context(ctx context.Context) *LoginCall {
+	l.ctx = ctx
+	return l
 }
 
 func (l *LoginCall) UserName(username string) *LoginCall {
@@ -266,23 +360,41 @@ func (l *LoginCall) Password(password string) *LoginCall {
 
 func (l *LoginCall) Do() (err error) {
 	var out NasLoginResponse
-	err = l.s.exec(&out, "get", fmt.Sprintf("%s/qts/account_login", l.s.qbusNameSpace), fmt.Sprintf(`{"user":"%s","pwd":"%s"}`, l.username, l.password))
+	err = l.s.exec(l.ctx, &out, "qts.login", "get", "qts/account_login", loginPayload{l.username, l.password})
 	if err == nil && out.Code == 200 {
 		l.s.sid = out.Result.AuthSid
 	} else {
 		if err != nil {
-			err = logError(&QtsErr{Code: QtsErrorInternalError, Err: err})
+			err = l.s.logError(&QtsErr{Code: QtsErrorInternalError, Err: err})
 		} else {
-			err = logError(&QtsErr{Code: QtsErrorBadRequest, QbusCode: out.ErrorCode, QbusErr: out.ErrorMsg})
+			err = l.s.logError(&QtsErr{Code: QtsErrorBadRequest, QbusCode: out.ErrorCode, QbusErr: out.ErrorMsg})
 		}
 	}
 	return
 }
 
-func NewClient(qbusNameSpace string, debugMode bool) *Service {
+// Option configures a Service created by NewClient.
+type Option func(*Service)
+
+// WithLogger makes the Service log failed calls and, at debug level or in
+// debug mode, the qbus commands it runs to l instead of slog.Default. The sid
+// and password in command payloads are redacted.
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Service) {
+		s.logger = l
+	}
+}
+
+// NewClient returns a Service calling qbus in qbusNameSpace. In debugMode
+// every qbus command is logged at info level.
+func NewClient(qbusNameSpace string, debugMode bool, opts ...Option) *Service {
 	s := &Service{}
 	s.qbusNameSpace = qbusNameSpace
-	s.s = sh.NewSession()
-	s.s.ShowCMD = debugMode
+	s.executor = CommandExecutor{}
+	s.debug = debugMode
+	s.logger = slog.Default()
+	for _, opt := range opts {
+		opt(s)
+	}
 	return s
 }
@@ -1,13 +1,28 @@
 package qts_test
 
 import (
-	"reflect"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
-	"github.com/bouk/monkey"
-	"github.com/codeskyblue/go-sh"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 
+	"github.com/qeek-dev/qeek-api-go-client/metrics"
 	"github.com/qeek-dev/qeek-api-go-client/qbus/qts/v1"
 	"github.com/qeek-dev/qeek-api-go-client/test"
 )
@@ -23,13 +38,42 @@ func setupSidTestCase(t *testing.T) (QtsTestCaseSuite, func(t *testing.T)) {
 	s.validSidResponse = `{"code":200,"errorCode":0,"errorMsg":"","result":null}`
 	s.inValidSidResponse = `{"code":400,"errorCode":4000201,"errorMsg":"NAS sid is not valid","result":null}`
 
-	s.qts = qts.NewClient("com.qnap.dj2", true)
+	s.qts = qts.NewClient("com.qnap.dj2", true, qts.WithExecutor(qbusNotFound))
 
 	return s, func(t *testing.T) {
 
 	}
 }
 
+// replies returns an Executor answering successive qbus commands with outputs.
+func replies(outputs ...string) qts.Executor {
+	i := 0
+	return qts.ExecutorFunc(func(ctx context.Context, verb, path string, payload []byte) ([]byte, error) {
+		if i >= len(outputs) {
+			return nil, fmt.Errorf("unexpected qbus command %s %s", verb, path)
+		}
+		i++
+		return []byte(outputs[i-1]), nil
+	})
+}
+
+// qbusNotFound fails every command as when the qbus binary is not installed.
+var qbusNotFound = qts.ExecutorFunc(func(ctx context.Context, verb, path string, payload []byte) ([]byte, error) {
+	return nil, &exec.Error{Name: "qbus", Err: exec.ErrNotFound}
+})
+
+// withExecutor returns a sub test setup replacing the client with one running
+// qbus commands with e, verifying sid first when not empty.
+func (s *QtsTestCaseSuite) withExecutor(e qts.Executor, sid string) test.SetupSubTest {
+	return func(t *testing.T) func(t *testing.T) {
+		s.qts = qts.NewClient("com.qnap.dj2", true, qts.WithExecutor(e))
+		if sid != "" {
+			s.qts.Verify().Sid(sid).Do()
+		}
+		return func(t *testing.T) {}
+	}
+}
+
 func TestLoginCall_Do(t *testing.T) {
 	s, teardownTestCase := setupSidTestCase(t)
 	defer teardownTestCase(t)
@@ -49,28 +93,14 @@ func TestLoginCall_Do(t *testing.T) {
 			name:          "success",
 			givenUserName: "admin",
 			givenPassword: "zxcv",
-			setupSubTest: func(t *testing.T) func(t *testing.T) {
-				monkey.PatchInstanceMethod(reflect.TypeOf((*sh.Session)(nil)), "Output", func(_ *sh.Session) (out []byte, err error) {
-					return []byte(`{"code": 200,"errorCode": 0,"errorMsg": "","result": {"authPassed": 1,"authSid": "uyvoud8k","isAdmin": 1}}`), nil
-				})
-				return func(t *testing.T) {
-					defer monkey.UnpatchAll()
-				}
-			},
+			setupSubTest:  s.withExecutor(replies(`{"code": 200,"errorCode": 0,"errorMsg": "","result": {"authPassed": 1,"authSid": "uyvoud8k","isAdmin": 1}}`), ""),
 		},
 		{
 			name:          "fail with invalid password",
 			givenUserName: "admin",
 			givenPassword: "dddd",
 			wantErrCode:   qts.QtsErrorBadRequest,
-			setupSubTest: func(t *testing.T) func(t *testing.T) {
-				monkey.PatchInstanceMethod(reflect.TypeOf((*sh.Session)(nil)), "Output", func(_ *sh.Session) (out []byte, err error) {
-					return []byte(`{"code": 400,"errorCode": 4000203,"errorMsg": "Authentication failed","result": null}`), nil
-				})
-				return func(t *testing.T) {
-					defer monkey.UnpatchAll()
-				}
-			},
+			setupSubTest:  s.withExecutor(replies(`{"code": 400,"errorCode": 4000203,"errorMsg": "Authentication failed","result": null}`), ""),
 		},
 		{
 			name:               "fail with qbus not found",
@@ -78,7 +108,7 @@ func TestLoginCall_Do(t *testing.T) {
 			givenUserName:      "admin",
 			givenPassword:      "zxcv",
 			wantErrCode:        qts.QtsErrorInternalError,
-			setupSubTest:       test.EmptySubTest(),
+			setupSubTest:       s.withExecutor(qbusNotFound, ""),
 		},
 	}
 
@@ -112,48 +142,27 @@ func TestVerifySidCall_Do(t *testing.T) {
 		setupSubTest test.SetupSubTest
 	}{
 		{
-			name:     "success with valid sid",
-			givenSid: "hcm3ipzf",
-			setupSubTest: func(t *testing.T) func(t *testing.T) {
-				monkey.PatchInstanceMethod(reflect.TypeOf((*sh.Session)(nil)), "Output", func(ss *sh.Session) (out []byte, err error) {
-					return []byte(s.validSidResponse), nil
-				})
-				return func(t *testing.T) {
-					defer monkey.UnpatchAll()
-				}
-			},
+			name:         "success with valid sid",
+			givenSid:     "hcm3ipzf",
+			setupSubTest: s.withExecutor(replies(s.validSidResponse), ""),
 		},
 		{
-			name:        "fail with invalid sid",
-			givenSid:    "oh0n736f",
-			wantErrCode: qts.QtsErrorBadRequest,
-			setupSubTest: func(t *testing.T) func(t *testing.T) {
-				monkey.PatchInstanceMethod(reflect.TypeOf((*sh.Session)(nil)), "Output", func(ss *sh.Session) (out []byte, err error) {
-					return []byte(s.inValidSidResponse), nil
-				})
-				return func(t *testing.T) {
-					defer monkey.UnpatchAll()
-				}
-			},
+			name:         "fail with invalid sid",
+			givenSid:     "oh0n736f",
+			wantErrCode:  qts.QtsErrorBadRequest,
+			setupSubTest: s.withExecutor(replies(s.inValidSidResponse), ""),
 		},
 		{
-			name:        "fail with empty sid",
-			givenSid:    "",
-			wantErrCode: qts.QtsErrorBadRequest,
-			setupSubTest: func(t *testing.T) func(t *testing.T) {
-				monkey.PatchInstanceMethod(reflect.TypeOf((*sh.Session)(nil)), "Output", func(ss *sh.Session) (out []byte, err error) {
-					return []byte(`{"code": 400,"errorCode": 4000200,"errorMsg": "'sid' is not specified or not found.","result": null}`), nil
-				})
-				return func(t *testing.T) {
-					defer monkey.UnpatchAll()
-				}
-			},
+			name:         "fail with empty sid",
+			givenSid:     "",
+			wantErrCode:  qts.QtsErrorBadRequest,
+			setupSubTest: s.withExecutor(replies(`{"code": 400,"errorCode": 4000200,"errorMsg": "'sid' is not specified or not found.","result": null}`), ""),
 		},
 		{
 			name:         "fail with qbus not found",
 			givenSid:     "oh0n736f",
 			wantErrCode:  qts.QtsErrorInternalError,
-			setupSubTest: test.EmptySubTest(),
+			setupSubTest: s.withExecutor(qbusNotFound, ""),
 		},
 	}
 
@@ -193,53 +202,23 @@ func TestNasUsersCall_Do(t *testing.T) {
 				{"hykuan@qnap.com", 0, []string{"everyone"}, "TCH", "hykuan", "/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/hykuan/avatar/portrait.jpg"},
 				{"cutedogspark@gmail.com", 1, []string{"administrators", "everyone"}, "auto", "gary", "/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/gary/avatar/portrait.jpg"},
 			},
-			setupSubTest: func(t *testing.T) func(t *testing.T) {
-				mockCount := 0
-				monkey.PatchInstanceMethod(reflect.TypeOf((*sh.Session)(nil)), "Output", func(ss *sh.Session) (out []byte, err error) {
-					switch mockCount {
-					case 0:
-						out = []byte(s.validSidResponse)
-					case 1:
-						out = []byte(`{"code":200,"errorCode":0,"errorMsg":"","result":[{"avatar":"/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/admin/avatar/portrait.jpg","email":"garychen@qnap.com","enable":1,"group":["administrators","everyone"],"lang":"auto","name":"admin"},{"avatar":"/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/hykuan/avatar/portrait.jpg","email":"hykuan@qnap.com","enable":0,"group":["everyone"],"lang":"TCH","name":"hykuan"},{"avatar":"/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/gary/avatar/portrait.jpg","email":"cutedogspark@gmail.com","enable":1,"group":["administrators","everyone"],"lang":"auto","name":"gary"}]}`)
-					}
-					mockCount++
-					return
-				})
-
-				s.qts.Verify().Sid("valid-sid-mocked").Do()
-
-				return func(t *testing.T) {
-					defer monkey.UnpatchAll()
-				}
-			},
+			setupSubTest: s.withExecutor(replies(
+				s.validSidResponse,
+				`{"code":200,"errorCode":0,"errorMsg":"","result":[{"avatar":"/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/admin/avatar/portrait.jpg","email":"garychen@qnap.com","enable":1,"group":["administrators","everyone"],"lang":"auto","name":"admin"},{"avatar":"/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/hykuan/avatar/portrait.jpg","email":"hykuan@qnap.com","enable":0,"group":["everyone"],"lang":"TCH","name":"hykuan"},{"avatar":"/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/gary/avatar/portrait.jpg","email":"cutedogspark@gmail.com","enable":1,"group":["administrators","everyone"],"lang":"auto","name":"gary"}]}`,
+			), "valid-sid-mocked"),
 		},
 		{
 			name:        "fail with invalid sid",
 			wantErrCode: qts.QtsErrorBadRequest,
-			setupSubTest: func(t *testing.T) func(t *testing.T) {
-				mockCount := 0
-				monkey.PatchInstanceMethod(reflect.TypeOf((*sh.Session)(nil)), "Output", func(ss *sh.Session) (out []byte, err error) {
-					switch mockCount {
-					case 0:
-						out = []byte(s.inValidSidResponse)
-					case 1:
-						out = []byte(`{"code":200,"errorCode":0,"errorMsg":"","result":[{"avatar":"/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/admin/avatar/portrait.jpg","email":"garychen@qnap.com","enable":1,"group":["administrators","everyone"],"lang":"auto","name":"admin"},{"avatar":"/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/hykuan/avatar/portrait.jpg","email":"hykuan@qnap.com","enable":0,"group":["everyone"],"lang":"TCH","name":"hykuan"},{"avatar":"/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/gary/avatar/portrait.jpg","email":"cutedogspark@gmail.com","enable":1,"group":["administrators","everyone"],"lang":"auto","name":"gary"}]}`)
-					}
-					mockCount++
-					return
-				})
-
-				s.qts.Verify().Sid("invalid-sid-mocked").Do()
-
-				return func(t *testing.T) {
-					defer monkey.UnpatchAll()
-				}
-			},
+			setupSubTest: s.withExecutor(replies(
+				s.inValidSidResponse,
+				`{"code":200,"errorCode":0,"errorMsg":"","result":[{"avatar":"/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/admin/avatar/portrait.jpg","email":"garychen@qnap.com","enable":1,"group":["administrators","everyone"],"lang":"auto","name":"admin"},{"avatar":"/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/hykuan/avatar/portrait.jpg","email":"hykuan@qnap.com","enable":0,"group":["everyone"],"lang":"TCH","name":"hykuan"},{"avatar":"/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/gary/avatar/portrait.jpg","email":"cutedogspark@gmail.com","enable":1,"group":["administrators","everyone"],"lang":"auto","name":"gary"}]}`,
+			), "invalid-sid-mocked"),
 		},
 		{
 			name:         "fail with qbus not found",
 			wantErrCode:  qts.QtsErrorInternalError,
-			setupSubTest: test.EmptySubTest(),
+			setupSubTest: s.withExecutor(qbusNotFound, ""),
 		},
 	}
 
@@ -285,56 +264,26 @@ func TestNasUserCall_Do(t *testing.T) {
 			wantNasAccount: qts.NasUserResult{
 				"garychen@qnap.com", 1, []string{"administrators", "everyone"}, "auto", "admin", "/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/admin/avatar/portrait.jpg",
 			},
-			setupSubTest: func(t *testing.T) func(t *testing.T) {
-				mockCount := 0
-				monkey.PatchInstanceMethod(reflect.TypeOf((*sh.Session)(nil)), "Output", func(ss *sh.Session) (out []byte, err error) {
-					switch mockCount {
-					case 0:
-						out = []byte(s.validSidResponse)
-					case 1:
-						out = []byte(`{"code":200,"errorCode":0,"errorMsg":"","result":{"avatar":"/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/admin/avatar/portrait.jpg","email":"garychen@qnap.com","enable":1,"group":["administrators","everyone"],"lang":"auto","name":"admin"}}`)
-					}
-					mockCount++
-					return
-				})
-
-				s.qts.Verify().Sid("valid-sid-mocked").Do()
-
-				return func(t *testing.T) {
-					defer monkey.UnpatchAll()
-				}
-			},
+			setupSubTest: s.withExecutor(replies(
+				s.validSidResponse,
+				`{"code":200,"errorCode":0,"errorMsg":"","result":{"avatar":"/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/admin/avatar/portrait.jpg","email":"garychen@qnap.com","enable":1,"group":["administrators","everyone"],"lang":"auto","name":"admin"}}`,
+			), "valid-sid-mocked"),
 		},
 		{
 			name:          "fail with no match route for the path",
 			givenValidSid: "oh0n736f",
 			givenUsername: "ddd",
 			wantErrCode:   qts.QtsErrorBadRequest,
-			setupSubTest: func(t *testing.T) func(t *testing.T) {
-				mockCount := 0
-				monkey.PatchInstanceMethod(reflect.TypeOf((*sh.Session)(nil)), "Output", func(ss *sh.Session) (out []byte, err error) {
-					switch mockCount {
-					case 0:
-						out = []byte(s.validSidResponse)
-					case 1:
-						out = []byte(`{"code":400,"errorCode":4000202,"errorMsg":"User dfdf not exist","result": null}`)
-					}
-					mockCount++
-					return
-				})
-
-				s.qts.Verify().Sid("valid-sid-mocked").Do()
-
-				return func(t *testing.T) {
-					defer monkey.UnpatchAll()
-				}
-			},
+			setupSubTest: s.withExecutor(replies(
+				s.validSidResponse,
+				`{"code":400,"errorCode":4000202,"errorMsg":"User dfdf not exist","result": null}`,
+			), "valid-sid-mocked"),
 		},
 		{
 			name:          "fail with qbus not found",
 			givenValidSid: "oh0n736f",
 			wantErrCode:   qts.QtsErrorInternalError,
-			setupSubTest:  test.EmptySubTest(),
+			setupSubTest:  s.withExecutor(qbusNotFound, ""),
 		},
 	}
 
@@ -374,55 +323,24 @@ func TestNasMeCall_Do(t *testing.T) {
 			wantNasMe: qts.NasUserResult{
 				"garychen@qnap.com", 1, []string{}, "auto", "admin", "/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/admin/avatar/portrait.jpg",
 			},
-			setupSubTest: func(t *testing.T) func(t *testing.T) {
-				mockCount := 0
-				monkey.PatchInstanceMethod(reflect.TypeOf((*sh.Session)(nil)), "Output", func(ss *sh.Session) (out []byte, err error) {
-					switch mockCount {
-					case 0:
-						out = []byte(s.validSidResponse)
-					case 1:
-						out = []byte(`{"code":200,"errorCode":0,"errorMsg":"","result":{"user":"admin"}}`)
-					case 2:
-						out = []byte(`{"code":200,"errorCode":0,"errorMsg":"","result":{"avatar":"/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/admin/avatar/portrait.jpg","email":"garychen@qnap.com","enable":1,"group":[],"lang":"auto","name":"admin"}}`)
-					}
-					mockCount++
-					return
-				})
-
-				s.qts.Verify().Sid("hcm3ipzf").Do()
-
-				return func(t *testing.T) {
-					defer monkey.UnpatchAll()
-				}
-			},
+			setupSubTest: s.withExecutor(replies(
+				s.validSidResponse,
+				`{"code":200,"errorCode":0,"errorMsg":"","result":{"user":"admin"}}`,
+				`{"code":200,"errorCode":0,"errorMsg":"","result":{"avatar":"/share/CACHEDEV1_DATA/.qpkg/DJ2-Live-X/middleware/qeek/../../tmp/share/user/nas/admin/avatar/portrait.jpg","email":"garychen@qnap.com","enable":1,"group":[],"lang":"auto","name":"admin"}}`,
+			), "hcm3ipzf"),
 		},
 		{
 			name:        "get nas me fail with invalid sid",
 			wantErrCode: qts.QtsErrorBadRequest,
-			setupSubTest: func(t *testing.T) func(t *testing.T) {
-				mockCount := 0
-				monkey.PatchInstanceMethod(reflect.TypeOf((*sh.Session)(nil)), "Output", func(ss *sh.Session) (out []byte, err error) {
-					switch mockCount {
-					case 0:
-						out = []byte(s.inValidSidResponse)
-					case 1:
-						out = []byte(`{"code":400,"errorCode":4000201,"errorMsg":"NAS sid is not valid","result":null}`)
-					}
-					mockCount++
-					return
-				})
-
-				s.qts.Verify().Sid("invalid-sid").Do()
-
-				return func(t *testing.T) {
-					defer monkey.UnpatchAll()
-				}
-			},
+			setupSubTest: s.withExecutor(replies(
+				s.inValidSidResponse,
+				`{"code":400,"errorCode":4000201,"errorMsg":"NAS sid is not valid","result":null}`,
+			), "invalid-sid"),
 		},
 		{
 			name:         "fail with qbus not found",
 			wantErrCode:  qts.QtsErrorInternalError,
-			setupSubTest: test.EmptySubTest(),
+			setupSubTest: s.withExecutor(qbusNotFound, ""),
 		},
 	}
 
@@ -444,3 +362,208 @@ func TestNasMeCall_Do(t *testing.T) {
 		})
 	}
 }
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	s := qts.NewClient("com.qnap.dj2", true, qts.WithLogger(logger), qts.WithExecutor(qbusNotFound))
+
+	s.Login().UserName("admin").Password("zxcv").Do()
+
+	got := buf.String()
+	assert.NotContains(t, got, "zxcv")
+	assert.Contains(t, got, `"msg":"qbus command"`)
+	assert.Contains(t, got, `"path":"com.qnap.dj2/qts/account_login"`)
+	assert.Contains(t, got, `"payload":"{\"pwd\":\"[REDACTED]\",\"user\":\"admin\"}"`)
+	assert.Contains(t, got, `"msg":"qts call failed"`)
+}
+
+func TestWithTracerProvider(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tt := []struct {
+		name          string
+		givenExecutor qts.Executor
+		wantCode      codes.Code
+		wantAttrs     []attribute.KeyValue
+	}{
+		{
+			name:          "success",
+			givenExecutor: replies(`{"code": 200,"errorCode": 0,"errorMsg": "","result": {"authPassed": 1,"authSid": "uyvoud8k","isAdmin": 1}}`),
+			wantCode:      codes.Unset,
+			wantAttrs:     []attribute.KeyValue{attribute.Int("qbus.code", 200)},
+		},
+		{
+			name:          "fail with invalid password",
+			givenExecutor: replies(`{"code": 400,"errorCode": 4000203,"errorMsg": "Authentication failed","result": null}`),
+			wantCode:      codes.Error,
+			wantAttrs:     []attribute.KeyValue{attribute.Int("qbus.code", 400), attribute.Int("qbus.error_code", 4000203)},
+		},
+		{
+			name:          "fail with qbus not found",
+			givenExecutor: qbusNotFound,
+			wantCode:      codes.Error,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			exporter.Reset()
+			s := qts.NewClient("com.qnap.dj2", false, qts.WithTracerProvider(tp), qts.WithLogger(logger), qts.WithExecutor(tc.givenExecutor))
+
+			ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+			s.Login().Context(ctx).UserName("admin").Password("zxcv").Do()
+			parent.End()
+
+			spans := exporter.GetSpans()
+			if assert.Len(t, spans, 2) {
+				got := spans[0]
+				assert.Equal(t, "qts.login", got.Name)
+				assert.Equal(t, parent.SpanContext().SpanID(), got.Parent.SpanID())
+				assert.Equal(t, tc.wantCode, got.Status.Code)
+				assert.Contains(t, got.Attributes, attribute.String("qbus.verb", "get"))
+				assert.Contains(t, got.Attributes, attribute.String("qbus.path", "com.qnap.dj2/qts/account_login"))
+				for _, a := range tc.wantAttrs {
+					assert.Contains(t, got.Attributes, a)
+				}
+			}
+		})
+	}
+}
+
+func TestWithMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c, err := metrics.NewCollector(reg)
+	if !assert.NoError(t, err) {
+		return
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	s := qts.NewClient("com.qnap.dj2", false, qts.WithMetrics(c), qts.WithLogger(logger), qts.WithExecutor(qbusNotFound))
+	s.Users().Do()
+
+	s = qts.NewClient("com.qnap.dj2", false, qts.WithMetrics(c), qts.WithLogger(logger), qts.WithExecutor(replies(
+		`{"code":400,"errorCode":4000201,"errorMsg":"NAS sid is not valid","result":null}`,
+		`{"code":200,"errorCode":0,"errorMsg":"","result":{"user":"admin"}}`,
+		`{"code":200,"errorCode":0,"errorMsg":"","result":{"email":"garychen@qnap.com","enable":1,"group":[],"lang":"auto","name":"admin"}}`,
+	)))
+	s.Verify().Sid("invalid").Do()
+	s.Me().Do()
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP qeek_client_requests_total Number of client calls by operation, outcome and error code.
+# TYPE qeek_client_requests_total counter
+qeek_client_requests_total{client="qts",code="200",operation="qts.me",outcome="success"} 1
+qeek_client_requests_total{client="qts",code="200",operation="qts.user",outcome="success"} 1
+qeek_client_requests_total{client="qts",code="4000201",operation="qts.verify_sid",outcome="error"} 1
+qeek_client_requests_total{client="qts",code="50001",operation="qts.users",outcome="error"} 1
+`), "qeek_client_requests_total"))
+}
+
+func TestLoginCall_Payload(t *testing.T) {
+	var gotVerb, gotPath string
+	var gotPayload map[string]string
+	e := qts.ExecutorFunc(func(ctx context.Context, verb, path string, payload []byte) ([]byte, error) {
+		gotVerb, gotPath = verb, path
+		if err := json.Unmarshal(payload, &gotPayload); err != nil {
+			t.Fatalf("%v, unexpected payload %s", err, payload)
+		}
+		return []byte(`{"code": 200,"errorCode": 0,"errorMsg": "","result": {"authPassed": 1,"authSid": "uyvoud8k","isAdmin": 1}}`), nil
+	})
+	s := qts.NewClient("com.qnap.dj2", false, qts.WithExecutor(e))
+
+	err := s.Login().UserName("admin").Password(`zx"cv\`).Do()
+	assert.NoError(t, err)
+	assert.Equal(t, "get", gotVerb)
+	assert.Equal(t, "com.qnap.dj2/qts/account_login", gotPath)
+	assert.Equal(t, map[string]string{"user": "admin", "pwd": `zx"cv\`}, gotPayload)
+	assert.Equal(t, "uyvoud8k", s.GetSid())
+}
+
+func TestCommandExecutor_Exec(t *testing.T) {
+	echo, err := exec.LookPath("echo")
+	if err != nil {
+		t.Skip("echo is not installed, skip test")
+	}
+
+	tt := []struct {
+		name     string
+		givenCtx func() (context.Context, context.CancelFunc)
+		wantOut  string
+		wantErr  error
+	}{
+		{
+			name:     "success",
+			givenCtx: func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			wantOut:  `get com.qnap.dj2/qts/users {"sid":"hcm3ipzf"}` + "\n",
+		},
+		{
+			name: "fail with canceled context",
+			givenCtx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := tc.givenCtx()
+			defer cancel()
+
+			out, err := qts.CommandExecutor{Path: echo}.Exec(ctx, "get", "com.qnap.dj2/qts/users", []byte(`{"sid":"hcm3ipzf"}`))
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantOut, string(out))
+		})
+	}
+}
+
+func TestCommandExecutor_ExecKilled(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "qbus")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\nexec sleep 10\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		name     string
+		givenCtx func() (context.Context, context.CancelFunc)
+		wantErr  error
+	}{
+		{
+			name: "fail when canceled while running",
+			givenCtx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(100*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			wantErr: context.Canceled,
+		},
+		{
+			name: "fail when the deadline passes while running",
+			givenCtx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 100*time.Millisecond)
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := tc.givenCtx()
+			defer cancel()
+
+			start := time.Now()
+			_, err := qts.CommandExecutor{Path: bin}.Exec(ctx, "get", "com.qnap.dj2/qts/users", []byte(`{"sid":"hcm3ipzf"}`))
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.True(t, time.Since(start) < 5*time.Second, "the command was expected to be killed")
+		})
+	}
+}
@@ -0,0 +1,4 @@
+package qtstest
+
+// CodePermissionDenied exposes codePermissionDenied to the tests of the package.
+const CodePermissionDenied = codePermissionDenied
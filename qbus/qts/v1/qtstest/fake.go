@@ -0,0 +1,299 @@
+// Package qtstest provides an in-memory fake of the QTS qbus commands used by
+// the qts client, for tests that cannot run qbus on a NAS.
+//
+//	f := qtstest.New("com.qnap.dj2")
+//	f.AddUser(qtstest.User{Name: "admin", Password: "zxcv", Groups: []string{"administrators", "everyone"}})
+//	s := f.Client()
+//	err := s.Login().UserName("admin").Password("zxcv").Do()
+package qtstest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qeek-dev/qeek-api-go-client/qbus/qts/v1"
+)
+
+// AdminGroup is the group whose members are administrators.
+const AdminGroup = "administrators"
+
+// DefaultSidTTL is the lifetime of the sids issued by a Fake.
+const DefaultSidTTL = 30 * time.Minute
+
+// codePermissionDenied is the qbus error code the fake reports for commands
+// reserved to administrators. The real code is not documented; use Fail to
+// reproduce the one of a given QTS firmware.
+const codePermissionDenied = 4000204
+
+// User is a NAS account seeded into a Fake.
+type User struct {
+	Name     string
+	Password string
+	Email    string
+	Lang     string
+	Avatar   string // path of the avatar picture
+	Disabled bool
+	Groups   []string
+}
+
+// IsAdmin reports whether u is a member of AdminGroup.
+func (u User) IsAdmin() bool {
+	for _, g := range u.Groups {
+		if g == AdminGroup {
+			return true
+		}
+	}
+	return false
+}
+
+type session struct {
+	user    string
+	expires time.Time
+}
+
+type failure struct {
+	code int
+	msg  string
+}
+
+// Fake is a qts.Executor answering qbus commands under a namespace from
+// memory. Users and groups are seeded with AddUser; sids are issued by
+// account_login and expire after SidTTL. Commands on users other than the
+// signed in one, and the user list, are reserved to administrators.
+// It is safe for concurrent use.
+type Fake struct {
+	// SidTTL is the lifetime of issued sids, DefaultSidTTL when 0.
+	SidTTL time.Duration
+	// Now returns the current time, time.Now when nil.
+	Now func() time.Time
+
+	namespace string
+
+	mu       sync.Mutex
+	users    map[string]*User
+	sessions map[string]session
+	failures map[string][]failure
+}
+
+// New returns a Fake serving the qbus namespace, e.g. "com.qnap.dj2".
+func New(namespace string) *Fake {
+	return &Fake{
+		namespace: namespace,
+		users:     map[string]*User{},
+		sessions:  map[string]session{},
+		failures:  map[string][]failure{},
+	}
+}
+
+// Client returns a qts.Service running its commands on f.
+func (f *Fake) Client(opts ...qts.Option) *qts.Service {
+	opts = append([]qts.Option{qts.WithExecutor(f)}, opts...)
+	return qts.NewClient(f.namespace, false, opts...)
+}
+
+// AddUser seeds u, replacing any user with the same name.
+func (f *Fake) AddUser(u User) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.users[u.Name] = &u
+}
+
+// Login issues a sid for the user without checking the password.
+func (f *Fake) Login(name string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.issueSid(name)
+}
+
+// Expire makes sid invalid, as if its lifetime had passed.
+func (f *Fake) Expire(sid string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.sessions, sid)
+}
+
+// Fail makes the next command on path, relative to the namespace, e.g.
+// "qts/users" or "qts/account_login", fail with the qbus error code and
+// message. Failures on a path are used in the order they were added.
+func (f *Fake) Fail(path string, code int, msg string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures[path] = append(f.failures[path], failure{code, msg})
+}
+
+// reply is the JSON output of a qbus command.
+type reply struct {
+	Code      int         `json:"code"`
+	ErrorCode int         `json:"errorCode"`
+	ErrorMsg  string      `json:"errorMsg"`
+	Result    interface{} `json:"result"`
+}
+
+func ok(result interface{}) reply {
+	return reply{Code: 200, Result: result}
+}
+
+func fail(code int, msg string) reply {
+	return reply{Code: 400, ErrorCode: code, ErrorMsg: msg}
+}
+
+type userResult struct {
+	Avatar string   `json:"avatar"`
+	Email  string   `json:"email"`
+	Enable int      `json:"enable"`
+	Group  []string `json:"group"`
+	Lang   string   `json:"lang"`
+	Name   string   `json:"name"`
+}
+
+func (u *User) result() userResult {
+	r := userResult{Avatar: u.Avatar, Email: u.Email, Enable: 1, Group: u.Groups, Lang: u.Lang, Name: u.Name}
+	if u.Disabled {
+		r.Enable = 0
+	}
+	if r.Group == nil {
+		r.Group = []string{}
+	}
+	return r
+}
+
+// Exec implements qts.Executor. Commands outside the namespace or on unknown
+// paths fail like a qbus binary exiting with an error.
+func (f *Fake) Exec(ctx context.Context, verb, path string, payload []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rel, found := strings.CutPrefix(path, f.namespace+"/")
+	if !found {
+		return nil, fmt.Errorf("qbus: unknown namespace of %s", path)
+	}
+	if verb != "get" {
+		return nil, fmt.Errorf("qbus: unsupported verb %s", verb)
+	}
+	var p struct {
+		Sid  string `json:"sid"`
+		User string `json:"user"`
+		Pwd  string `json:"pwd"`
+	}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, fmt.Errorf("qbus: invalid payload: %v", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r, err := f.serve(rel, p.Sid, p.User, p.Pwd)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(r)
+}
+
+func (f *Fake) serve(path, sid, user, pwd string) (reply, error) {
+	if fs := f.failures[path]; len(fs) > 0 {
+		f.failures[path] = fs[1:]
+		return fail(fs[0].code, fs[0].msg), nil
+	}
+
+	if path == "qts/account_login" {
+		u, found := f.users[user]
+		if !found || u.Disabled || u.Password != pwd {
+			return fail(qts.QbusErrorAuthFailed, "Authentication failed"), nil
+		}
+		isAdmin := 0
+		if u.IsAdmin() {
+			isAdmin = 1
+		}
+		return ok(map[string]interface{}{"authPassed": 1, "authSid": f.issueSid(u.Name), "isAdmin": isAdmin}), nil
+	}
+
+	me, r := f.authenticate(sid)
+	if me == nil {
+		return r, nil
+	}
+	switch {
+	case path == "qts/verify_sid":
+		return ok(nil), nil
+	case path == "qts/user/me":
+		return ok(map[string]string{"user": me.Name}), nil
+	case path == "qts/users":
+		if !me.IsAdmin() {
+			return fail(codePermissionDenied, "Permission denied"), nil
+		}
+		names := make([]string, 0, len(f.users))
+		for name := range f.users {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		users := make([]userResult, 0, len(names))
+		for _, name := range names {
+			users = append(users, f.users[name].result())
+		}
+		return ok(users), nil
+	case strings.HasPrefix(path, "qts/user/"):
+		name, sub, _ := strings.Cut(strings.TrimPrefix(path, "qts/user/"), "/")
+		if sub != "" && sub != "avatar" {
+			break
+		}
+		if name != me.Name && !me.IsAdmin() {
+			return fail(codePermissionDenied, "Permission denied"), nil
+		}
+		u, found := f.users[name]
+		if !found {
+			return fail(qts.QbusErrorUserNotExist, fmt.Sprintf("User %s not exist", name)), nil
+		}
+		if sub == "avatar" {
+			return ok(map[string]string{"path": u.Avatar}), nil
+		}
+		return ok(u.result()), nil
+	}
+	return reply{}, fmt.Errorf("qbus: no match route for the path %s/%s", f.namespace, path)
+}
+
+// authenticate returns the user signed in with sid, or the reply of a
+// missing or invalid sid.
+func (f *Fake) authenticate(sid string) (*User, reply) {
+	if sid == "" {
+		return nil, fail(qts.QbusErrorSidNotSpecified, "'sid' is not specified or not found.")
+	}
+	s, found := f.sessions[sid]
+	if !found || !f.now().Before(s.expires) {
+		delete(f.sessions, sid)
+		return nil, fail(qts.QbusErrorInvalidSid, "NAS sid is not valid")
+	}
+	u, found := f.users[s.user]
+	if !found || u.Disabled {
+		return nil, fail(qts.QbusErrorInvalidSid, "NAS sid is not valid")
+	}
+	return u, reply{}
+}
+
+func (f *Fake) issueSid(name string) string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	sid := hex.EncodeToString(b)
+	ttl := f.SidTTL
+	if ttl == 0 {
+		ttl = DefaultSidTTL
+	}
+	f.sessions[sid] = session{user: name, expires: f.now().Add(ttl)}
+	return sid
+}
+
+func (f *Fake) now() time.Time {
+	if f.Now != nil {
+		return f.Now()
+	}
+	return time.Now()
+}
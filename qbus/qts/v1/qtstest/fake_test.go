@@ -0,0 +1,206 @@
+package qtstest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qeek-dev/qeek-api-go-client/qbus/qts/v1"
+	"github.com/qeek-dev/qeek-api-go-client/qbus/qts/v1/qtstest"
+	"github.com/qeek-dev/qeek-api-go-client/test"
+)
+
+type FakeTestCaseSuite struct {
+	fake *qtstest.Fake
+	qts  *qts.Service
+	now  time.Time
+}
+
+func setupFakeTestCase(t *testing.T) (*FakeTestCaseSuite, func(t *testing.T)) {
+	s := &FakeTestCaseSuite{fake: qtstest.New("com.qnap.dj2"), now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s.fake.Now = func() time.Time { return s.now }
+	s.fake.AddUser(qtstest.User{Name: "admin", Password: "zxcv", Email: "garychen@qnap.com", Lang: "auto", Groups: []string{"administrators", "everyone"}, Avatar: "/tmp/admin/portrait.jpg"})
+	s.fake.AddUser(qtstest.User{Name: "hykuan", Password: "qwer", Email: "hykuan@qnap.com", Lang: "TCH", Groups: []string{"everyone"}})
+	s.fake.AddUser(qtstest.User{Name: "gone", Password: "asdf", Disabled: true})
+	s.qts = s.fake.Client()
+
+	return s, func(t *testing.T) {
+
+	}
+}
+
+// qbusCode returns the QbusCode of err, or 0 when err is not a qbus failure.
+func qbusCode(err error) int {
+	if e, ok := err.(*qts.QtsErr); ok && e.Code == qts.QtsErrorBadRequest {
+		return e.QbusCode
+	}
+	return 0
+}
+
+func TestFake_Login(t *testing.T) {
+	s, teardownTestCase := setupFakeTestCase(t)
+	defer teardownTestCase(t)
+
+	tt := []struct {
+		name          string
+		givenUserName string
+		givenPassword string
+		wantQbusCode  int
+		setupSubTest  test.SetupSubTest
+	}{
+		{
+			name:          "success",
+			givenUserName: "admin",
+			givenPassword: "zxcv",
+			setupSubTest:  test.EmptySubTest(),
+		},
+		{
+			name:          "fail with invalid password",
+			givenUserName: "admin",
+			givenPassword: "dddd",
+			wantQbusCode:  qts.QbusErrorAuthFailed,
+			setupSubTest:  test.EmptySubTest(),
+		},
+		{
+			name:          "fail with disabled user",
+			givenUserName: "gone",
+			givenPassword: "asdf",
+			wantQbusCode:  qts.QbusErrorAuthFailed,
+			setupSubTest:  test.EmptySubTest(),
+		},
+		{
+			name:          "fail with injected error",
+			givenUserName: "admin",
+			givenPassword: "zxcv",
+			wantQbusCode:  qts.QbusErrorSidNotSpecified,
+			setupSubTest: func(t *testing.T) func(t *testing.T) {
+				s.fake.Fail("qts/account_login", qts.QbusErrorSidNotSpecified, "'sid' is not specified or not found.")
+				return func(t *testing.T) {}
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			teardownSubTest := tc.setupSubTest(t)
+			defer teardownSubTest(t)
+
+			err := s.qts.Login().UserName(tc.givenUserName).Password(tc.givenPassword).Do()
+			if tc.wantQbusCode != 0 {
+				assert.Equal(t, tc.wantQbusCode, qbusCode(err))
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.NotEmpty(t, s.qts.GetSid())
+			}
+		})
+	}
+}
+
+func TestFake_VerifySid(t *testing.T) {
+	s, teardownTestCase := setupFakeTestCase(t)
+	defer teardownTestCase(t)
+
+	tt := []struct {
+		name         string
+		givenSid     func() string
+		wantQbusCode int
+	}{
+		{
+			name:     "success with valid sid",
+			givenSid: func() string { return s.fake.Login("admin") },
+		},
+		{
+			name:         "fail with empty sid",
+			givenSid:     func() string { return "" },
+			wantQbusCode: qts.QbusErrorSidNotSpecified,
+		},
+		{
+			name:         "fail with unknown sid",
+			givenSid:     func() string { return "oh0n736f" },
+			wantQbusCode: qts.QbusErrorInvalidSid,
+		},
+		{
+			name: "fail with expired sid",
+			givenSid: func() string {
+				sid := s.fake.Login("admin")
+				s.now = s.now.Add(qtstest.DefaultSidTTL)
+				return sid
+			},
+			wantQbusCode: qts.QbusErrorInvalidSid,
+		},
+		{
+			name: "fail with expired sid by Expire",
+			givenSid: func() string {
+				sid := s.fake.Login("admin")
+				s.fake.Expire(sid)
+				return sid
+			},
+			wantQbusCode: qts.QbusErrorInvalidSid,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := s.qts.Verify().Sid(tc.givenSid()).Do()
+			assert.Equal(t, tc.wantQbusCode, qbusCode(err))
+			if tc.wantQbusCode != 0 {
+				assert.Empty(t, s.qts.GetSid())
+			}
+		})
+	}
+}
+
+func TestFake_Users(t *testing.T) {
+	s, teardownTestCase := setupFakeTestCase(t)
+	defer teardownTestCase(t)
+
+	admin := s.fake.Client()
+	assert.NoError(t, admin.Login().UserName("admin").Password("zxcv").Do())
+	user := s.fake.Client()
+	assert.NoError(t, user.Login().UserName("hykuan").Password("qwer").Do())
+
+	me, err := admin.Me().Do()
+	if assert.NoError(t, err) {
+		assert.Equal(t, qts.NasUserResult{
+			Email: "garychen@qnap.com", Enable: 1, Group: []string{"administrators", "everyone"}, Lang: "auto", Name: "admin", Avatar: "/tmp/admin/portrait.jpg",
+		}, me)
+	}
+
+	users, err := admin.Users().Do()
+	if assert.NoError(t, err) && assert.Len(t, users, 3) {
+		assert.Equal(t, "hykuan", users[2].Name)
+		assert.Equal(t, 0, users[1].Enable)
+	}
+	_, err = user.Users().Do()
+	assert.Equal(t, qtstest.CodePermissionDenied, qbusCode(err))
+
+	u, err := admin.User().UserName("hykuan").Do()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "TCH", u.Lang)
+	}
+	_, err = admin.User().UserName("ddd").Do()
+	assert.Equal(t, qts.QbusErrorUserNotExist, qbusCode(err))
+	_, err = user.User().UserName("admin").Do()
+	assert.Equal(t, qtstest.CodePermissionDenied, qbusCode(err))
+	me, err = user.Me().Do()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "hykuan", me.Name)
+	}
+}
+
+func TestFake_Avatar(t *testing.T) {
+	s, teardownTestCase := setupFakeTestCase(t)
+	defer teardownTestCase(t)
+
+	sid := s.fake.Login("admin")
+	out, err := s.fake.Exec(context.Background(), "get", "com.qnap.dj2/qts/user/admin/avatar", []byte(`{"sid":"`+sid+`"}`))
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"code":200,"errorCode":0,"errorMsg":"","result":{"path":"/tmp/admin/portrait.jpg"}}`, string(out))
+	}
+
+	_, err = s.fake.Exec(context.Background(), "get", "com.qnap.other/qts/users", []byte(`{"sid":"`+sid+`"}`))
+	assert.Error(t, err)
+}
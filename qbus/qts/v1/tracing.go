@@ -0,0 +1,61 @@
+package qts
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const instrumentationName = "github.com/qeek-dev/qeek-api-go-client/qbus/qts/v1"
+
+// WithTracerProvider makes the Service record a span for every qbus command
+// with tracers from tp, as a child of the span in the call's Context.
+// Without it no spans are recorded.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(s *Service) {
+		s.tracer = tp.Tracer(instrumentationName)
+	}
+}
+
+// qbusResponse is implemented by every response type through the embedded Response.
+type qbusResponse interface {
+	response() *Response
+}
+
+func (r *Response) response() *Response {
+	return r
+}
+
+func (s *Service) startSpan(ctx context.Context, op, verb, path string) (context.Context, trace.Span) {
+	tracer := s.tracer
+	if tracer == nil {
+		tracer = noop.NewTracerProvider().Tracer(instrumentationName)
+	}
+	return tracer.Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("qts.operation", op),
+			attribute.String("qbus.verb", verb),
+			attribute.String("qbus.path", path),
+		),
+	)
+}
+
+// endSpan records the qbus status decoded into out, or err, on span and ends it.
+func endSpan(span trace.Span, out pointer, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if r, ok := out.(qbusResponse); ok {
+		res := r.response()
+		span.SetAttributes(attribute.Int("qbus.code", res.Code))
+		if res.Code != 200 {
+			span.SetAttributes(attribute.Int("qbus.error_code", res.ErrorCode))
+			span.SetStatus(codes.Error, res.ErrorMsg)
+		}
+	}
+	span.End()
+}